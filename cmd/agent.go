@@ -0,0 +1,444 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	agentSocket     string
+	agentTTL        time.Duration
+	agentForeground bool
+
+	agentAddFiles   []string
+	agentAddKeyring bool
+	agentAddName    string
+	agentAddTTL     time.Duration
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run an agent that keeps unlocked keys for other go-enc commands",
+	Long: `Start an agent in the background that holds identities and passphrases,
+unlocked once, for decrypt, cat and exec. They send it the stanzas of their
+files to unwrap, or have it derive the key of a passphrase file, so the keys
+never leave it. The agent listens on a 0600 unix socket, printed as a line for
+the shell to evaluate:
+
+  eval "$(go-enc agent)"
+  go-enc agent add -i identity.key
+
+Commands find the agent through $GO_ENC_AGENT_SOCK and use it when none of -i,
+--share or -p are given. Keys are held for --ttl, or as long as agent add says.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if agentTTL < 0 {
+			return fmt.Errorf("--ttl must not be negative, got %s", agentTTL)
+		}
+		_, err := kdfMemory()
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+		path := agentSocketPath()
+
+		if !agentForeground {
+			pid, err := startAgent(path)
+			if err != nil {
+				return res.finish(os.Stdout, "", 0, err)
+			}
+			printText("%s=%s; export %s;\necho Agent pid %d;\n", genc.AgentSockEnv, path, genc.AgentSockEnv, pid)
+			return res.finish(os.Stdout, path, 0, nil)
+		}
+
+		l, err := genc.ListenAgent(path)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		printText("%s=%s; export %s;\n", genc.AgentSockEnv, path, genc.AgentSockEnv)
+
+		maxMemory, _ := kdfMemory()
+		agent := &genc.Agent{TTL: agentTTL, MaxKDFMemory: maxMemory}
+		err = agent.Serve(cmd.Context(), l)
+		return res.finish(os.Stdout, path, 0, err)
+	},
+}
+
+var agentAddCmd = &cobra.Command{
+	Use:   "add [-i FILE]... | add --keyring | add [-p PASSPHRASE]",
+	Short: "Hand identities or a passphrase to the agent",
+	Long: `Hand the agent the identities of identity files or ssh private keys, those of
+the keyring, or without -i and --keyring a passphrase, given with -p or
+prompted for. Each is held under a name, the file's path, "keyring:NAME" or
+"passphrase" unless --name says otherwise, replacing a key held under it.`,
+	Args: cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if agentAddTTL < 0 {
+			return fmt.Errorf("--ttl must not be negative, got %s", agentAddTTL)
+		}
+		// 0 holds the key until it is removed, as it does for the agent,
+		// leaving --ttl out uses the agent's
+		if agentAddTTL == 0 && cmd.Flags().Changed("ttl") {
+			agentAddTTL = genc.AgentNoExpiry
+		}
+		if agentAddKeyring && len(agentAddFiles) > 0 {
+			return errors.New("--keyring cannot be combined with --identity")
+		}
+		if agentAddName != "" && (agentAddKeyring || len(agentAddFiles) > 1) {
+			return errors.New("--name names a single key")
+		}
+		if agentAddKeyring || len(agentAddFiles) > 0 {
+			return nil
+		}
+		return requirePassphrase(false)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+		client := genc.NewAgentClient(agentSocketPath())
+
+		var names []string
+		var err error
+		switch {
+		case agentAddKeyring:
+			names, err = agentAddFromKeyring(client)
+		case len(agentAddFiles) > 0:
+			names, err = agentAddIdentities(client)
+		default:
+			name := agentAddName
+			if name == "" {
+				name = "passphrase"
+			}
+			if err = client.AddPassphrase(name, passphrase, agentAddTTL); err == nil {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			printText("added to the agent: %s\n", name)
+		}
+		return res.finish(os.Stdout, "", 0, err)
+	},
+}
+
+var agentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys the agent holds",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		keys, err := genc.NewAgentClient(agentSocketPath()).Keys()
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		res.AgentKeys = keys
+		if len(keys) == 0 {
+			printText("the agent holds no keys\n")
+		}
+		for _, k := range keys {
+			kind := "identity"
+			if k.Passphrase {
+				kind = "passphrase"
+			}
+			expires := "until removed"
+			if !k.Expires.IsZero() {
+				expires = "expires in " + time.Until(k.Expires).Round(time.Second).String()
+			}
+			printText("%s\t%s\t%s\n", k.Name, kind, expires)
+		}
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+var agentRemoveCmd = &cobra.Command{
+	Use:   "remove NAME",
+	Short: "Wipe a key the agent holds",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		if err := genc.NewAgentClient(agentSocketPath()).Remove(args[0]); err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		printText("removed from the agent: %s\n", args[0])
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+var agentLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the agent with a passphrase, it refuses everything until unlocked",
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return requireLockPassphrase(true)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		if err := genc.NewAgentClient(agentSocketPath()).Lock(passphrase); err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		printText("agent locked\n")
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+var agentUnlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the agent with the passphrase it was locked with",
+	Args:  cobra.NoArgs,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return requireLockPassphrase(false)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		if err := genc.NewAgentClient(agentSocketPath()).Unlock(passphrase); err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		printText("agent unlocked\n")
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Wipe every key and stop the agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		if err := genc.NewAgentClient(agentSocketPath()).Stop(); err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		printText("agent stopped\n")
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+// agentSocketPath returns --socket, or the socket genc.AgentSocketPath finds.
+func agentSocketPath() string {
+	if agentSocket != "" {
+		return agentSocket
+	}
+	return genc.AgentSocketPath()
+}
+
+// startAgent runs the agent in the background, detached from the terminal,
+// and waits until it answers on path.
+func startAgent(path string) (int, error) {
+	client := genc.NewAgentClient(path)
+	if _, err := client.Keys(); err == nil {
+		return 0, fmt.Errorf("%w: an agent is already listening on %s", genc.ErrAgent, path)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", genc.ErrAgent, err)
+	}
+
+	c := exec.Command(exe, "agent", "--foreground", "--socket", path,
+		"--ttl", agentTTL.String(), "--max-kdf-memory", strconv.Itoa(maxKDFMemory))
+	c.SysProcAttr = detachAttr()
+	if err := c.Start(); err != nil {
+		return 0, fmt.Errorf("%w: %w", genc.ErrAgent, err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- c.Wait() }()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		if _, err := client.Keys(); err == nil {
+			return c.Process.Pid, nil
+		}
+
+		select {
+		case err := <-exited:
+			return 0, fmt.Errorf("%w: agent exited before listening on %s: %v", genc.ErrAgent, path, err)
+		case <-timeout:
+			c.Process.Kill()
+			return 0, fmt.Errorf("%w: agent did not listen on %s in time", genc.ErrAgent, path)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// agentAddIdentities hands the agent every -i file, prompting for the
+// passphrase of encrypted ssh keys.
+func agentAddIdentities(client *genc.AgentClient) ([]string, error) {
+	var names []string
+	for _, path := range agentAddFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return names, fmt.Errorf("failed to read identity file: %w", err)
+		}
+
+		name := agentAddName
+		if name == "" {
+			if name, err = filepath.Abs(path); err != nil {
+				name = path
+			}
+		}
+
+		err = client.AddIdentity(name, data, sshPassphrase(path), agentAddTTL)
+		genc.Wipe(data)
+		if err != nil {
+			return names, fmt.Errorf("%s: %w", path, err)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// agentAddFromKeyring hands the agent every identity of the keyring.
+func agentAddFromKeyring(client *genc.AgentClient) ([]string, error) {
+	k, err := openKeyring(false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range k.Sorted() {
+		if e.Identity == "" {
+			continue
+		}
+		name := "keyring:" + e.Name
+		if err := client.AddIdentity(name, []byte(e.Identity), sshPassphrase("keyring key "+e.Name), agentAddTTL); err != nil {
+			return names, fmt.Errorf("keyring key %s: %w", e.Name, err)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: the keyring holds no identities", genc.ErrKeyring)
+	}
+	return names, nil
+}
+
+// requireLockPassphrase makes sure there is a passphrase to lock or unlock
+// the agent with, -p or prompted for, twice when locking.
+func requireLockPassphrase(confirm bool) error {
+	if len(passphrase) > 0 {
+		return nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("lock passphrase is required, use -p or run from a terminal")
+	}
+
+	pass, err := promptSecret(fd, "Lock passphrase: ")
+	if err != nil {
+		return err
+	}
+	if confirm {
+		again, err := promptSecret(fd, "Confirm lock passphrase: ")
+		if err != nil {
+			genc.Wipe(pass)
+			return err
+		}
+		defer genc.Wipe(again)
+
+		if !bytes.Equal(pass, again) {
+			genc.Wipe(pass)
+			return errors.New("passphrases do not match")
+		}
+	}
+
+	passphrase = pass
+	genc.LockMemory(passphrase)
+	return nil
+}
+
+// agentIdentities returns the agent named by GO_ENC_AGENT_SOCK as an
+// identity, when it is unlocked and holds a key that may open the file at
+// path.
+func agentIdentities(path string) []genc.Identity {
+	sock := os.Getenv(genc.AgentSockEnv)
+	if sock == "" {
+		return nil
+	}
+
+	client := genc.NewAgentClient(sock)
+	keys, err := client.Keys()
+	if err != nil {
+		log.Printf("not using the agent: %v\n", err)
+		return nil
+	}
+
+	passphrases, publicKeys := fileKeyKinds(path)
+	for _, k := range keys {
+		if k.Passphrase && passphrases || !k.Passphrase && publicKeys {
+			return []genc.Identity{client.Identity()}
+		}
+	}
+	return nil
+}
+
+// fileKeyKinds reports whether the genc file at path opens with a passphrase
+// and whether it is encrypted to public keys.
+func fileKeyKinds(path string) (passphrases, publicKeys bool) {
+	in, err := os.Open(path)
+	if err != nil {
+		return false, false
+	}
+	defer in.Close()
+
+	info, err := genc.Inspect(in)
+	if err != nil || info.Format != "genc" {
+		return false, false
+	}
+	for _, st := range info.Stanzas {
+		switch st.Type {
+		case "passphrase":
+			passphrases = true
+		case "shares":
+		default:
+			publicKeys = true
+		}
+	}
+	return passphrases || info.KDF != nil, publicKeys
+}
+
+func init() {
+	agentCmd.PersistentFlags().StringVar(&agentSocket, "socket", "", "socket of the agent, $GO_ENC_AGENT_SOCK or one in $XDG_RUNTIME_DIR by default")
+	agentCmd.Flags().DurationVar(&agentTTL, "ttl", time.Hour, "how long keys are held when added without --ttl, 0 holds them until removed")
+	agentCmd.Flags().BoolVar(&agentForeground, "foreground", false, "run the agent in the foreground instead of starting it in the background")
+	addKDFFlag(agentCmd)
+
+	agentAddCmd.Flags().StringArrayVarP(&agentAddFiles, "identity", "i", nil, "hand the agent the identities in this file, or an ssh private key, may be repeated")
+	agentAddCmd.Flags().BoolVar(&agentAddKeyring, "keyring", false, "hand the agent every identity of the keyring")
+	agentAddCmd.Flags().StringVar(&agentAddName, "name", "", "name to hold the key under")
+	agentAddCmd.Flags().DurationVar(&agentAddTTL, "ttl", 0, "how long the agent holds the key, 0 until it is removed, the agent's own --ttl by default")
+
+	agentCmd.AddCommand(agentAddCmd, agentListCmd, agentRemoveCmd, agentLockCmd, agentUnlockCmd, agentStopCmd)
+	rootCmd.AddCommand(agentCmd)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+)
+
+var catCmd = &cobra.Command{
+	Use:         "cat",
+	Short:       "Decrypt a file to stdout",
+	Long:        `Decrypt a file that was encrypted with this tool and write the plaintext to stdout without touching the disk.`,
+	Annotations: map[string]string{"results": "stderr"},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkGencFile(file); err != nil {
+			return err
+		}
+		if _, err := kdfMemory(); err != nil {
+			return err
+		}
+		if err := checkIter(); err != nil {
+			return err
+		}
+		if err := loadKeyfile(); err != nil {
+			return err
+		}
+		if err := loadVerifyKeys(); err != nil {
+			return err
+		}
+		return requireKey()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, file)
+		maxMemory, _ := kdfMemory()
+
+		in, err := os.Open(file)
+		if err != nil {
+			return res.finish(os.Stderr, "", 0, fmt.Errorf("%w: %w", genc.ErrOpenFile, err))
+		}
+		defer in.Close()
+
+		sig, err := inputSignature(file)
+		if err != nil {
+			return res.finish(os.Stderr, "", 0, err)
+		}
+
+		out := &countingWriter{w: os.Stdout}
+		if err := genc.DecryptStream(passphrase, in, out, genc.Options{Context: cmd.Context(), Streaming: true, MaxKDFMemory: maxMemory, OpenSSLIterations: opensslIter, Identities: identities, Keyfile: keyfile, VerifyWith: verifyKeys, Signature: sig}); err != nil {
+			return res.finish(os.Stderr, "-", out.n, fmt.Errorf("decryption failed: %w", err))
+		}
+
+		return res.finish(os.Stderr, "-", out.n, nil)
+	},
+}
+
+func init() {
+	catCmd.MarkPersistentFlagRequired("file")
+	catCmd.MarkPersistentFlagRequired("passphrase")
+	addFollowFlag(catCmd)
+	addKDFFlag(catCmd)
+	addIdentityFlag(catCmd)
+	addShareFlag(catCmd)
+	addVerifyFlag(catCmd)
+	addIterFlag(catCmd)
+	addKeyfileFlag(catCmd)
+	rootCmd.AddCommand(catCmd)
+}
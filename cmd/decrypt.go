@@ -18,46 +18,49 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/irrisdev/go-enc/genc"
 	"github.com/spf13/cobra"
 )
 
-var outPath string
+var (
+	outPath  string
+	preserve bool
+)
 
 var decryptCmd = &cobra.Command{
 	Use:   "decrypt",
 	Short: "Decrypt a file",
-	Long:  `Decrypt a file that was encrypted with this tool.`,
+	Long: `Decrypt a file that was encrypted with this tool, or with age, gpg --symmetric
+or openssl enc -aes-256-cbc -pbkdf2. The format is detected from the file.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		// Check if input file exists
-		info, err := os.Stat(file)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("file does not exist: %s", file)
-			}
-			return fmt.Errorf("error accessing file %s: %w", file, err)
+		if err := checkGencFile(file); err != nil {
+			return err
 		}
-
-		if info.IsDir() {
-			return fmt.Errorf("cannot decrypt directories: %s", file)
+		if _, err := kdfMemory(); err != nil {
+			return err
+		}
+		if err := checkIter(); err != nil {
+			return err
 		}
 
-		// Validate file has .genc extension
-		if !strings.HasSuffix(file, ".genc") {
-			return fmt.Errorf("file must have .genc extension: %s", file)
+		if _, err := overwriteOptions(); err != nil {
+			return err
 		}
 
-		// Check if file is readable
-		f, err := os.Open(file)
-		if err != nil {
-			return fmt.Errorf("file is not readable: %w", err)
+		if err := requireKey(); err != nil {
+			return err
+		}
+		if err := loadKeyfile(); err != nil {
+			return err
+		}
+		if err := loadVerifyKeys(); err != nil {
+			return err
 		}
-		f.Close()
 
 		// Validate output path if provided
 		if outPath != "" {
@@ -78,23 +81,51 @@ var decryptCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		var outputFile string
-
-		if outPath == "" {
-			outputFile = strings.TrimSuffix(file, ".genc")
-			err = genc.Decrypt(passphrase, file)
-		} else {
-			outputFile = outPath
-			err = genc.Decrypt(passphrase, file, outPath)
+		res := newResult(cmd, file)
+
+		outputFile := outPath
+		if outputFile == "" {
+			outputFile = genc.PlainName(file)
 		}
 
+		opts, _ := overwriteOptions()
+		opts.Output = outputFile
+		opts.Preserve = preserve
+		opts.Context = cmd.Context()
+		opts.Wait = waitLock
+		opts.FollowSymlinks = followSymlinks
+		opts.MaxKDFMemory, _ = kdfMemory()
+		opts.OpenSSLIterations = opensslIter
+		opts.Identities = identities
+		opts.Keyfile = keyfile
+		opts.VerifyWith = verifyKeys
+
+		var match genc.PassphraseMatch
+		opts.PassphraseMatch = &match
+
+		err := genc.Decrypt(passphrase, file, opts)
+		if errors.Is(err, genc.ErrSpecialFile) && skipSpecial {
+			printText("skipped: %v\n", err)
+			return res.finish(os.Stdout, "", 0, skipped{err})
+		}
 		if err != nil {
-			return fmt.Errorf("decryption failed: %w", err)
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("decryption failed: %w", err))
 		}
 
-		fmt.Printf("successfully decrypted: %s -> %s\n", file, outputFile)
-		return nil
+		var size int64
+		if info, err := os.Stat(outputFile); err == nil {
+			size = info.Size()
+		}
+
+		printText("successfully decrypted: %s -> %s\n", file, outputFile)
+		if match.Fallback {
+			printText("passphrase matched in %s form, re-encrypt the file to store it normalised\n", match.Form)
+		}
+
+		if match.Used {
+			res.Passphrase = match.Form.String()
+		}
+		return res.finish(os.Stdout, outputFile, size, nil)
 	},
 }
 
@@ -102,5 +133,15 @@ func init() {
 	decryptCmd.MarkPersistentFlagRequired("file")
 	decryptCmd.MarkPersistentFlagRequired("passphrase")
 	decryptCmd.Flags().StringVarP(&outPath, "outpath", "o", "", "output file path (optional)")
+	decryptCmd.Flags().BoolVar(&preserve, "preserve", false, "restore the original mode, owner, timestamps and user extended attributes")
+	addOverwriteFlags(decryptCmd)
+	addLockFlags(decryptCmd)
+	addInputFlags(decryptCmd)
+	addKDFFlag(decryptCmd)
+	addIdentityFlag(decryptCmd)
+	addShareFlag(decryptCmd)
+	addVerifyFlag(decryptCmd)
+	addIterFlag(decryptCmd)
+	addKeyfileFlag(decryptCmd)
 	rootCmd.AddCommand(decryptCmd)
 }
@@ -0,0 +1,348 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/irrisdev/go-enc/internal"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultEditor   = "vi"
+	editShredPasses = 3
+)
+
+var errEditInterrupted = fmt.Errorf("edit %w, changes discarded", genc.ErrInterrupted)
+
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit an encrypted file in place",
+	Long: `Decrypt a file into a private temporary directory, open it in $EDITOR and
+re-encrypt it if the content changed. The temporary copy is shredded afterwards.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkGencFile(file); err != nil {
+			return err
+		}
+		if _, err := kdfMemory(); err != nil {
+			return err
+		}
+		if err := loadKeyfile(); err != nil {
+			return err
+		}
+		return requirePassphrase(false)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, file)
+
+		// edit the target so the rename below replaces it and keeps the link
+		target := file
+		if followSymlinks {
+			resolved, err := filepath.EvalSymlinks(file)
+			if err != nil {
+				return res.finish(os.Stdout, "", 0, fmt.Errorf("%w: %w", genc.ErrOpenFile, err))
+			}
+			if resolved != file {
+				log.Printf("following symlink %s -> %s\n", file, resolved)
+			}
+			target = resolved
+		}
+
+		// held until the edited file is renamed into place
+		unlock, err := genc.LockFiles(cmd.Context(), waitLock, target)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		defer unlock()
+
+		// the edit is saved with the passphrase alone, anything else would
+		// silently change who can decrypt the file
+		info, err := checkEditable(target)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		dir, err := privateTempDir()
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("failed to create temp directory: %w", err))
+		}
+
+		// shred everything in the temp dir, signals only cancel so this always runs
+		defer func() {
+			if err := shredDir(dir); err != nil {
+				log.Printf("failed to shred temp directory %s: %v\n", dir, err)
+			}
+		}()
+
+		// catch signals so the plaintext is never left behind, the root
+		// interrupt context is not used because SIGINT belongs to the editor
+		var editor atomic.Pointer[os.Process]
+		var interrupted atomic.Bool
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, interruptSignals...)
+		defer signal.Stop(sigs)
+
+		go func() {
+			for sig := range sigs {
+				// the editor shares our terminal and sees SIGINT itself,
+				// anything else ends the edit
+				if p := editor.Load(); p != nil {
+					if sig != os.Interrupt {
+						interrupted.Store(true)
+						p.Signal(sig)
+					}
+					continue
+				}
+
+				// stops decryption or encryption, deferred cleanup does the rest
+				interrupted.Store(true)
+				cancel()
+			}
+		}()
+
+		tmpPath := filepath.Join(dir, filepath.Base(genc.PlainName(file)))
+
+		var md genc.Metadata
+		before, err := decryptToFile(ctx, target, tmpPath, &md)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("decryption failed: %w", err))
+		}
+
+		name, editorCmd := editorCommand(tmpPath)
+		editorCmd.Stdin = os.Stdin
+		editorCmd.Stdout = os.Stdout
+		editorCmd.Stderr = os.Stderr
+
+		if interrupted.Load() {
+			return res.finish(os.Stdout, "", 0, errEditInterrupted)
+		}
+
+		if err := editorCmd.Start(); err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("failed to start editor %s: %w", name, err))
+		}
+		editor.Store(editorCmd.Process)
+		err = editorCmd.Wait()
+		editor.Store(nil)
+
+		if interrupted.Load() {
+			return res.finish(os.Stdout, "", 0, errEditInterrupted)
+		}
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("editor exited with error, changes discarded: %w", err))
+		}
+
+		after, size, err := hashFile(tmpPath)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("failed to read edited file: %w", err))
+		}
+
+		if bytes.Equal(before, after) {
+			printText("no changes: %s\n", file)
+			return res.finish(os.Stdout, "", size, nil)
+		}
+
+		// keep the stored metadata of the original, it was just modified
+		md.Mtime = time.Now()
+		if err := encryptInPlace(ctx, tmpPath, target, &md, info.Sparse); err != nil {
+			return res.finish(os.Stdout, "", size, fmt.Errorf("encryption failed: %w", err))
+		}
+
+		printText("successfully updated: %s\n", file)
+		return res.finish(os.Stdout, file, size, nil)
+	},
+}
+
+// editorCommand returns $EDITOR, or defaultEditor when it is empty, and the
+// command that runs it on path. Like git, an editor with arguments, quotes or
+// other shell syntax is run by sh, so a path with spaces can be quoted.
+func editorCommand(path string) (string, *exec.Cmd) {
+	name := strings.TrimSpace(os.Getenv("EDITOR"))
+	if name == "" {
+		name = defaultEditor
+	}
+	if !strings.ContainsAny(name, " \t\n\"'\\$`|&;<>()*?[]#~") {
+		return name, exec.Command(name, path)
+	}
+
+	if sh, err := exec.LookPath("sh"); err == nil {
+		return name, exec.Command(sh, "-c", name+` "$@"`, name, path)
+	}
+	fields := strings.Fields(name)
+	return name, exec.Command(fields[0], append(fields[1:], path)...)
+}
+
+// checkEditable refuses files that are not genc files encrypted with only a
+// passphrase, and signed files, whose signature edit cannot renew. It returns
+// what the file is encrypted with, to encrypt the edit the same way.
+func checkEditable(path string) (genc.FileInfo, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return genc.FileInfo{}, fmt.Errorf("%w: %w", genc.ErrOpenFile, err)
+	}
+	defer in.Close()
+
+	info, err := genc.Inspect(in)
+	if err != nil {
+		return info, err
+	}
+	if info.Format != "genc" || info.KDF == nil {
+		return info, fmt.Errorf("%w: edit only supports genc files encrypted with a passphrase alone", genc.ErrFormat)
+	}
+	if _, err := os.Lstat(path + genc.SignatureExt); err == nil {
+		return info, fmt.Errorf("%w: %s is signed and edit cannot sign it again, decrypt it and encrypt --sign-with instead", genc.ErrFormat, path)
+	}
+	return info, nil
+}
+
+// privateTempDir creates a 0700 directory, preferring memory backed /dev/shm.
+func privateTempDir() (string, error) {
+	var dir string
+	var err error
+
+	if info, statErr := os.Stat("/dev/shm"); statErr == nil && info.IsDir() {
+		dir, err = os.MkdirTemp("/dev/shm", "go-enc-edit-")
+	}
+	if dir == "" || err != nil {
+		dir, err = os.MkdirTemp("", "go-enc-edit-")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.Chmod(dir, 0o700); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// shredDir shreds every regular file below dir, including editor swap files,
+// and then removes the directory.
+func shredDir(dir string) error {
+	var shredErr error
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if err := internal.ShredFile(path, editShredPasses); err != nil && shredErr == nil {
+				shredErr = err
+			}
+		}
+		return nil
+	})
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return shredErr
+}
+
+// decryptToFile decrypts src into a new 0600 file at dst, decoding stored
+// metadata into md, and returns the sha256 of the plaintext.
+func decryptToFile(ctx context.Context, src, dst string, md *genc.Metadata) ([]byte, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", genc.ErrOpenFile, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", genc.ErrCreateFile, err)
+	}
+	defer out.Close()
+
+	h := sha256.New()
+	maxMemory, _ := kdfMemory()
+	if err := genc.DecryptStream(passphrase, in, io.MultiWriter(out, h), genc.Options{Context: ctx, Metadata: md, MaxKDFMemory: maxMemory, Keyfile: keyfile}); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), out.Close()
+}
+
+// encryptInPlace encrypts src with metadata md, sparse if the original was,
+// into a temp file beside dst and renames it over dst.
+func encryptInPlace(ctx context.Context, src, dst string, md *genc.Metadata, sparse bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("%w: %w", genc.ErrOpenFile, err)
+	}
+	defer in.Close()
+
+	out, err := internal.CreateAtomic(dst)
+	if err != nil {
+		return fmt.Errorf("%w: %w", genc.ErrCreateFile, err)
+	}
+	defer out.Abort()
+
+	if err := genc.EncryptStream(passphrase, in, out, genc.Options{Context: ctx, Metadata: md, Keyfile: keyfile, Sparse: sparse}); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", genc.ErrInterrupted, err)
+	}
+	if err := out.Commit(); err != nil {
+		return fmt.Errorf("%w: %w", genc.ErrSyncEncFile, err)
+	}
+
+	return nil
+}
+
+// hashFile returns the sha256 and size of the file at path.
+func hashFile(path string) ([]byte, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return h.Sum(nil), n, nil
+}
+
+func init() {
+	editCmd.MarkPersistentFlagRequired("file")
+	editCmd.MarkPersistentFlagRequired("passphrase")
+	addLockFlags(editCmd)
+	addFollowFlag(editCmd)
+	addKDFFlag(editCmd)
+	addKeyfileFlag(editCmd)
+	rootCmd.AddCommand(editCmd)
+}
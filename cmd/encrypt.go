@@ -21,69 +21,250 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/irrisdev/go-enc/genc"
 	"github.com/spf13/cobra"
 )
 
-var deleteOrigin bool
+var (
+	deleteOrigin bool
+	shredPasses  int
+	minStrength  string
+	weakPass     string
+	sparse       bool
+	fileFormat   string
+	splitArg     string
+	printShares  bool
+
+	// split is made from --split before the command runs
+	split *genc.SplitRecipient
+)
 
 var encryptCmd = &cobra.Command{
 	Use:   "encrypt",
 	Short: "Encrypt a file",
-	Long:  `Encrypt a file using AES encryption with the provided passphrase.`,
+	Long: `Encrypt a file using AES encryption with the provided passphrase, or to the
+public keys of recipients: keys created with keygen, age keys or ssh keys.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if len(passphrase) < MinPassLen {
-			return fmt.Errorf("passphrase must be at least %d characters, got %d", MinPassLen, len(passphrase))
+		if _, err := overwriteOptions(); err != nil {
+			return err
 		}
 
-		info, err := os.Stat(file)
+		if shredPasses < 0 {
+			return fmt.Errorf("--shred must not be negative, got %d", shredPasses)
+		}
+		if shredPasses > 0 && !deleteOrigin {
+			return errors.New("--shred requires --delete-origin")
+		}
+
+		strength, err := parseStrengthFlags()
 		if err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("file does not exist: %s", file)
+			return err
+		}
+
+		format, err := genc.ParseFormat(fileFormat)
+		if err != nil {
+			return err
+		}
+		if format == genc.FormatAge && sparse {
+			return errors.New("--sparse is not supported with --format age")
+		}
+
+		if err := parseRecipients(); err != nil {
+			return err
+		}
+		if printShares && splitArg == "" {
+			return errors.New("--print-shares requires --split")
+		}
+		if splitArg != "" {
+			if format == genc.FormatAge {
+				return errors.New("--split is not supported with --format age")
 			}
-			return fmt.Errorf("error accessing file %s: %w", file, err)
+			threshold, total, err := parseSplit(splitArg)
+			if err != nil {
+				return err
+			}
+			if split, err = genc.NewSplitRecipient(threshold, total); err != nil {
+				return err
+			}
+			recipients = append(recipients, split)
+		}
+		if withPass && len(recipients) == 0 {
+			return errors.New("--with-passphrase requires --recipient")
+		}
+		if format == genc.FormatAge && len(recipients) > 0 && (withPass || len(passphrase) > 0) {
+			return errors.New("--format age cannot combine a passphrase with --recipient")
+		}
+		if keyfilePath != "" && (len(recipients) > 0 || format == genc.FormatAge) {
+			return errors.New("--keyfile only works with a passphrase and --format genc")
+		}
+		if err := loadKeyfile(); err != nil {
+			return err
+		}
+		if err := loadSigningKey(); err != nil {
+			return err
 		}
 
-		if info.IsDir() {
-			return fmt.Errorf("cannot encrypt directories: %s", file)
+		// recipients replace the passphrase unless one is asked for as well
+		if len(recipients) > 0 && !withPass && len(passphrase) == 0 {
+			return checkInputFile(file, "encrypt")
 		}
 
-		// Check if file is readable
-		f, err := os.Open(file)
-		if err != nil {
-			return fmt.Errorf("file is not readable: %w", err)
+		if err := requirePassphrase(true); err != nil {
+			return err
+		}
+
+		if n := utf8.RuneCount(passphrase); n < MinPassLen {
+			return fmt.Errorf("passphrase must be at least %d characters, got %d", MinPassLen, n)
+		}
+
+		if err := checkStrength(passphrase, strength); err != nil {
+			return err
 		}
-		f.Close()
 
-		return nil
+		return checkInputFile(file, "encrypt")
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := genc.Encrypt(passphrase, file, deleteOrigin)
+		res := newResult(cmd, file)
+		output := file + "." + fileFormat
 
-		if deleteOrigin {
-			if errors.Is(err, genc.ErrRemoveOrigin) {
-				fmt.Println(err)
-			} else {
-				fmt.Println("original file deleted")
+		var size int64
+		if info, err := os.Stat(file); err == nil {
+			size = info.Size()
+		}
 
+		// shares are handed out before anything depends on them
+		if split != nil && !printShares {
+			paths, err := writeShares(output)
+			if err != nil {
+				return res.finish(os.Stdout, "", size, fmt.Errorf("failed to write shares: %w", err))
 			}
+			res.Shares = paths
+		}
+
+		opts, _ := overwriteOptions()
+		opts.DeleteOrigin = deleteOrigin
+		opts.ShredPasses = shredPasses
+		opts.Format, _ = genc.ParseFormat(fileFormat)
+		opts.Sparse = sparse
+		opts.Recipients = recipients
+		opts.Keyfile = keyfile
+		opts.SignWith = signingKey
+		opts.Context = cmd.Context()
+		opts.Wait = waitLock
+		opts.FollowSymlinks = followSymlinks
+
+		err := genc.Encrypt(passphrase, file, opts)
+
+		if errors.Is(err, genc.ErrSpecialFile) && skipSpecial {
+			printText("skipped: %v\n", err)
+			return res.finish(os.Stdout, "", 0, skipped{err})
+		}
+
+		// the encrypted file was written, only verifying, signing or removing
+		// the original failed
+		if errors.Is(err, genc.ErrVerify) || errors.Is(err, genc.ErrSign) || errors.Is(err, genc.ErrRemoveOrigin) {
+			printText("Successfully encrypted: %s -> %s\n", file, output)
+			return res.finish(os.Stdout, output, size, err)
 		}
 
 		if err != nil {
-			return fmt.Errorf("encryption failed: %w", err)
+			// the shares are useless without the file
+			for _, path := range res.Shares {
+				os.Remove(path)
+			}
+			res.Shares = nil
+			return res.finish(os.Stdout, "", size, fmt.Errorf("encryption failed: %w", err))
 		}
 
-		fmt.Printf("Successfully encrypted: %s -> %s.genc\n", file, file)
+		printText("Successfully encrypted: %s -> %s\n", file, output)
+		if signingKey != nil {
+			printText("signed by %s: %s%s\n", signingKey.Public(), output, genc.SignatureExt)
+			res.SignedBy = signingKey.Public().String()
+		}
+		if deleteOrigin {
+			printText("original file deleted\n")
+		}
+		if split != nil {
+			printSplit(res, output)
+		}
 
-		return nil
+		return res.finish(os.Stdout, output, size, nil)
 	},
 }
 
+// parseSplit parses --split as threshold/total.
+func parseSplit(s string) (int, int, error) {
+	k, n, ok := strings.Cut(s, "/")
+	threshold, errK := strconv.Atoi(k)
+	total, errN := strconv.Atoi(n)
+	if !ok || errK != nil || errN != nil {
+		return 0, 0, fmt.Errorf("invalid --split %q, must be threshold/total such as 3/5", s)
+	}
+	if threshold < 1 || threshold > total || total > 255 {
+		return 0, 0, fmt.Errorf("invalid --split %q, need 1 <= threshold <= total <= 255", s)
+	}
+	return threshold, total, nil
+}
+
+// writeShares writes each share of split to its own 0600 file beside output,
+// removing them all again if one cannot be written.
+func writeShares(output string) ([]string, error) {
+	var paths []string
+	for _, sh := range split.Shares() {
+		path := fmt.Sprintf("%s.share-%d", output, sh.Index())
+		data := fmt.Sprintf("# go-enc share %d of %d for %s, any %d of them decrypt it\n%s\n",
+			sh.Index(), sh.Total(), filepath.Base(output), sh.Threshold(), sh)
+
+		if err := writeNewFile(path, []byte(data)); err != nil {
+			for _, p := range paths {
+				os.Remove(p)
+			}
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// printSplit reports where the shares went, or with --print-shares prints
+// them and adds them to the result.
+func printSplit(res *result, output string) {
+	shares := split.Shares()
+	printText("split into %d shares, any %d of them decrypt %s\n", len(shares), shares[0].Threshold(), output)
+
+	if !printShares {
+		for _, path := range res.Shares {
+			printText("  %s\n", path)
+		}
+		return
+	}
+	for _, sh := range shares {
+		printText("share %d of %d: %s\n", sh.Index(), sh.Total(), sh)
+		res.Shares = append(res.Shares, sh.String())
+	}
+}
+
 func init() {
 	encryptCmd.MarkPersistentFlagRequired("file")
 	encryptCmd.MarkPersistentFlagRequired("passphrase")
-	encryptCmd.Flags().BoolVar(&deleteOrigin, "delete-origin", false, "remove original file after encryption")
+	encryptCmd.Flags().BoolVar(&deleteOrigin, "delete-origin", false, "remove original file after encryption has been verified")
+	encryptCmd.Flags().IntVar(&shredPasses, "shred", 0, "overwrite the original this many times before removing it (requires --delete-origin)")
+	addStrengthFlags(encryptCmd)
+	addRecipientFlags(encryptCmd, "encrypt to")
+	encryptCmd.Flags().BoolVar(&withPass, "with-passphrase", false, "with --recipient, also let a passphrase decrypt, prompted for without -p")
+	encryptCmd.Flags().StringVar(&fileFormat, "format", "genc", "file format: genc, or age for files age can decrypt, without metadata")
+	encryptCmd.Flags().BoolVar(&sparse, "sparse", false, "store runs of zero chunks as their length, reveals where the file is zero")
+	encryptCmd.Flags().StringVar(&splitArg, "split", "", "encrypt to a random key split into shares, threshold/total such as 3/5, written beside the output")
+	encryptCmd.Flags().BoolVar(&printShares, "print-shares", false, "with --split, print the shares instead of writing share files")
+	encryptCmd.Flags().StringVar(&signKeyPath, "sign-with", "", "sign the encrypted file with the key in this file from keygen --sign, into a .sig beside it")
+	addKeyfileFlag(encryptCmd)
+	addOverwriteFlags(encryptCmd)
+	addLockFlags(encryptCmd)
+	addInputFlags(encryptCmd)
 	rootCmd.AddCommand(encryptCmd)
-
 }
@@ -0,0 +1,164 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/irrisdev/go-enc/internal"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec -- command [args...]",
+	Short: "Run a command with decrypted dotenv secrets",
+	Long: `Decrypt a dotenv file in memory and run a command with its variables merged
+into the environment. Variables from the file override inherited ones. Signals sent
+to go-enc such as SIGTERM, SIGHUP and SIGINT are forwarded to the command, except
+Ctrl-C and other signals from the terminal while go-enc runs in the foreground,
+which reach the command directly. go-enc exits with the command's exit status.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: map[string]string{"results": "stderr"},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkGencFile(file); err != nil {
+			return err
+		}
+		if _, err := kdfMemory(); err != nil {
+			return err
+		}
+		if err := loadKeyfile(); err != nil {
+			return err
+		}
+		if err := loadVerifyKeys(); err != nil {
+			return err
+		}
+		return requireKey()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, file)
+		maxMemory, _ := kdfMemory()
+
+		in, err := os.Open(file)
+		if err != nil {
+			return res.finish(os.Stderr, "", 0, fmt.Errorf("%w: %w", genc.ErrOpenFile, err))
+		}
+		defer in.Close()
+
+		sig, err := inputSignature(file)
+		if err != nil {
+			return res.finish(os.Stderr, "", 0, err)
+		}
+
+		var plaintext bytes.Buffer
+		err = genc.DecryptStream(passphrase, in, &plaintext, genc.Options{Context: cmd.Context(), Streaming: true, MaxKDFMemory: maxMemory, Identities: identities, Keyfile: keyfile, VerifyWith: verifyKeys, Signature: sig})
+		genc.Wipe(passphrase)
+		if err != nil {
+			return res.finish(os.Stderr, "", 0, fmt.Errorf("decryption failed: %w", err))
+		}
+
+		size := int64(plaintext.Len())
+		vars, err := internal.ParseDotenv(plaintext.Bytes())
+		clear(plaintext.Bytes())
+		if err != nil {
+			return res.finish(os.Stderr, "", size, err)
+		}
+
+		env := os.Environ()
+		for _, v := range vars {
+			env = append(env, v.Key+"="+v.Value)
+		}
+
+		child := exec.Command(args[0], args[1:]...)
+		child.Env = env
+		child.Stdin = os.Stdin
+		child.Stdout = os.Stdout
+		child.Stderr = os.Stderr
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, forwardedSignals...)
+		signal.Notify(sigs, terminalSignals...)
+		defer signal.Stop(sigs)
+
+		if err := child.Start(); err != nil {
+			return res.finish(os.Stderr, "", size, fmt.Errorf("failed to start %s: %w", args[0], err))
+		}
+
+		go func() {
+			for sig := range sigs {
+				// the child gets these from the terminal itself when
+				// go-enc is in the foreground
+				if slices.Contains(terminalSignals, sig) && foreground() {
+					continue
+				}
+				child.Process.Signal(sig)
+			}
+		}()
+
+		err = child.Wait()
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return res.finish(os.Stderr, "", size, childExit{name: args[0], status: exitStatus(exitErr), err: exitErr})
+		}
+		if err != nil {
+			return res.finish(os.Stderr, "", size, fmt.Errorf("failed to run %s: %w", args[0], err))
+		}
+
+		return res.finish(os.Stderr, "", size, nil)
+	},
+}
+
+// childExit is returned when the command run by exec fails, go-enc exits
+// with its status.
+type childExit struct {
+	name   string
+	status int
+	err    *exec.ExitError
+}
+
+func (e childExit) Error() string { return fmt.Sprintf("%s: %v", e.name, e.err) }
+
+func (e childExit) Unwrap() error { return e.err }
+
+// exitStatus mirrors the shell convention of 128+n for children killed by signal n.
+func exitStatus(err *exec.ExitError) int {
+	if ws, ok := err.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return err.ExitCode()
+}
+
+func init() {
+	execCmd.MarkPersistentFlagRequired("file")
+	execCmd.MarkPersistentFlagRequired("passphrase")
+	addFollowFlag(execCmd)
+	addKDFFlag(execCmd)
+	addKeyfileFlag(execCmd)
+	addIdentityFlag(execCmd)
+	addShareFlag(execCmd)
+	addVerifyFlag(execCmd)
+	rootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,104 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-enc/genc"
+)
+
+// execHelperEnv makes the test binary run go-enc with the arguments after --
+// when started by TestExecForwardsSignals.
+const execHelperEnv = "GO_ENC_TEST_EXEC"
+
+func TestExecForwardsSignals(t *testing.T) {
+	if os.Getenv(execHelperEnv) != "" {
+		rootCmd.SetArgs(flag.Args())
+		Execute()
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	id, err := genc.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(idFile, []byte(id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var enc bytes.Buffer
+	if err := genc.EncryptStream(nil, strings.NewReader("A=1\n"), &enc, genc.Options{Recipients: []genc.Recipient{id.Recipient()}}); err != nil {
+		t.Fatal(err)
+	}
+	encFile := filepath.Join(dir, "s.env.genc")
+	if err := os.WriteFile(encFile, enc.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the child exits 7 on any of the signals, go-enc passes that on
+	script := `trap 'exit 7' INT QUIT TERM HUP; echo ready; while :; do sleep 0.1; done`
+	args := []string{"exec", "-f", encFile, "-i", idFile, "--", "sh", "-c", script}
+
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGHUP} {
+		t.Run(sig.String(), func(t *testing.T) {
+			parent := exec.Command(os.Args[0], append([]string{"-test.run=^TestExecForwardsSignals$", "--"}, args...)...)
+			parent.Env = append(os.Environ(), execHelperEnv+"=1")
+			// a session of its own has no terminal that would send the
+			// signal to the child as well
+			parent.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+			out, err := parent.StdoutPipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parent.Start(); err != nil {
+				t.Fatal(err)
+			}
+			timer := time.AfterFunc(10*time.Second, func() {
+				syscall.Kill(-parent.Process.Pid, syscall.SIGKILL)
+			})
+			defer timer.Stop()
+
+			line, err := bufio.NewReader(out).ReadString('\n')
+			if err != nil || line != "ready\n" {
+				t.Fatalf("child did not start: %q %v", line, err)
+			}
+			if err := parent.Process.Signal(sig); err != nil {
+				t.Fatal(err)
+			}
+
+			var exitErr *exec.ExitError
+			if err := parent.Wait(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 7 {
+				t.Fatalf("got %v, want exit status 7", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/irrisdev/go-enc/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	noClobber  bool
+	force      bool
+	backupMode string
+
+	waitLock bool
+	noWait   bool
+
+	followSymlinks bool
+	skipSpecial    bool
+
+	maxKDFMemory int
+	opensslIter  int
+)
+
+// addOverwriteFlags registers the flags that decide what happens to an existing output file.
+func addOverwriteFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&noClobber, "no-clobber", false, "never replace an existing output file (default)")
+	cmd.Flags().BoolVar(&force, "force", false, "replace an existing output file")
+	cmd.Flags().StringVar(&backupMode, "backup", "none", "back up an existing output file before replacing it: none, simple or numbered")
+	cmd.MarkFlagsMutuallyExclusive("no-clobber", "force")
+	cmd.MarkFlagsMutuallyExclusive("no-clobber", "backup")
+}
+
+// addLockFlags registers the flags that decide what happens when another
+// go-enc process is working on the same files.
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&waitLock, "wait", false, "wait for other go-enc processes using the same files to finish")
+	cmd.Flags().BoolVar(&noWait, "no-wait", false, "fail straight away when the files are in use (default)")
+	cmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
+}
+
+// addFollowFlag registers --follow-symlinks, without it symlinked inputs are refused.
+func addFollowFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "accept a symlink as input and use the file it points to")
+}
+
+// addInputFlags registers the symlink and special file policy flags.
+func addInputFlags(cmd *cobra.Command) {
+	addFollowFlag(cmd)
+	cmd.Flags().BoolVar(&skipSpecial, "skip-special", false, "skip FIFOs, sockets and devices instead of failing")
+}
+
+// addKDFFlag registers --max-kdf-memory for commands that derive a key from a file header.
+func addKDFFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&maxKDFMemory, "max-kdf-memory", int(internal.DefaultMaxKDFMemory/1024), "most memory in MiB a file may ask key derivation to use")
+}
+
+// addIterFlag registers --iter for commands that read openssl enc files.
+func addIterFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&opensslIter, "iter", genc.DefaultOpenSSLIterations, "pbkdf2 iterations of openssl enc files, as given to openssl -iter")
+}
+
+// checkIter validates --iter.
+func checkIter() error {
+	if opensslIter < 1 {
+		return fmt.Errorf("--iter must be positive, got %d", opensslIter)
+	}
+	return nil
+}
+
+// kdfMemory returns --max-kdf-memory in KiB as genc.Options expects it.
+func kdfMemory() (uint32, error) {
+	lo, hi := int(internal.MinKDFMemory/1024), math.MaxUint32/1024
+	if maxKDFMemory < lo || maxKDFMemory > hi {
+		return 0, fmt.Errorf("--max-kdf-memory must be between %d and %d MiB, got %d", lo, hi, maxKDFMemory)
+	}
+	return uint32(maxKDFMemory) * 1024, nil
+}
+
+// overwriteOptions builds the genc overwrite policy from the flags.
+func overwriteOptions() (genc.Options, error) {
+	var opts genc.Options
+
+	backup, err := genc.ParseBackup(backupMode)
+	if err != nil {
+		return opts, err
+	}
+	opts.Backup = backup
+
+	if force {
+		opts.Overwrite = genc.Force
+	}
+
+	return opts, nil
+}
+
+// checkInputFile validates path against the symlink and special file policy
+// and checks that it is readable. Special files pass with --skip-special, the
+// command then reports them as skipped.
+func checkInputFile(path, verb string) error {
+	link, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file does not exist: %s", path)
+		}
+		return fmt.Errorf("error accessing file %s: %w", path, err)
+	}
+
+	if link.Mode()&fs.ModeSymlink != 0 && !followSymlinks {
+		return fmt.Errorf("%s is a symlink, use --follow-symlinks to %s the file it points to", path, verb)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error accessing file %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("cannot %s directories: %s", verb, path)
+	}
+
+	// opening a FIFO would block until something writes to it
+	if !info.Mode().IsRegular() {
+		if skipSpecial {
+			return nil
+		}
+		return fmt.Errorf("%s is not a regular file, use --skip-special to skip it", path)
+	}
+
+	// Check if file is readable
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("file is not readable: %w", err)
+	}
+	f.Close()
+
+	return nil
+}
+
+// checkGencFile validates that path is a readable, regular file with one of
+// the extensions go-enc decrypts.
+func checkGencFile(path string) error {
+	if err := checkInputFile(path, "decrypt"); err != nil {
+		return err
+	}
+
+	// Validate file has a known extension
+	if genc.PlainName(path) == path {
+		return fmt.Errorf("file must have a %s extension: %s", strings.Join(genc.Extensions, ", "), path)
+	}
+
+	return nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+)
+
+var importOut string
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Convert a gpg or openssl encrypted file into a .genc file",
+	Long: `Decrypt a file made with gpg --symmetric or openssl enc -aes-256-cbc -pbkdf2 and
+encrypt it into a new .genc file in one streaming pass, with the same passphrase
+or to the recipients given with -r. The plaintext never touches the disk and the
+new file is only kept if the old one passed its integrity check.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkInputFile(file, "import"); err != nil {
+			return err
+		}
+		if _, err := overwriteOptions(); err != nil {
+			return err
+		}
+		if err := checkIter(); err != nil {
+			return err
+		}
+		if err := parseRecipients(); err != nil {
+			return err
+		}
+		if keyfilePath != "" && len(recipients) > 0 {
+			return errors.New("--keyfile only works without --recipient")
+		}
+		if err := loadKeyfile(); err != nil {
+			return err
+		}
+		return requirePassphrase(false)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, file)
+
+		output := importOut
+		if output == "" {
+			output = genc.PlainName(file) + ".genc"
+		}
+
+		opts, _ := overwriteOptions()
+		opts.Output = output
+		opts.Recipients = recipients
+		opts.Keyfile = keyfile
+		opts.OpenSSLIterations = opensslIter
+		opts.Context = cmd.Context()
+		opts.Wait = waitLock
+		opts.FollowSymlinks = followSymlinks
+
+		if err := genc.Import(passphrase, file, opts); err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("import failed: %w", err))
+		}
+
+		var size int64
+		if info, err := os.Stat(output); err == nil {
+			size = info.Size()
+		}
+
+		printText("successfully imported: %s -> %s\n", file, output)
+		return res.finish(os.Stdout, output, size, nil)
+	},
+}
+
+func init() {
+	importCmd.MarkPersistentFlagRequired("file")
+	importCmd.MarkPersistentFlagRequired("passphrase")
+	importCmd.Flags().StringVarP(&importOut, "outpath", "o", "", "output file path, the input without its extension plus .genc by default")
+	addRecipientFlags(importCmd, "encrypt the new file to")
+	addOverwriteFlags(importCmd)
+	addLockFlags(importCmd)
+	addFollowFlag(importCmd)
+	addIterFlag(importCmd)
+	importCmd.Flags().StringVar(&keyfilePath, "keyfile", "", "combine the passphrase of the new file with this keyfile")
+	rootCmd.AddCommand(importCmd)
+}
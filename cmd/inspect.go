@@ -0,0 +1,97 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Show how a file is encrypted",
+	Long: `Show the format version, key derivation and key stanzas of an encrypted file
+without decrypting it. Stanzas are listed by type only, never by recipient.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkGencFile(file)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, file)
+
+		in, err := os.Open(file)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("%w: %w", genc.ErrOpenFile, err))
+		}
+		defer in.Close()
+
+		info, err := genc.Inspect(in)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		res.Info = &info
+
+		if info.Version == 0 {
+			printText("%s: %s file, encrypted with a passphrase, convert it with import\n", file, info.Format)
+			return res.finish(os.Stdout, "", 0, nil)
+		}
+
+		printText("%s: %s format version %d\n", file, info.Format, info.Version)
+		if info.KDF != nil {
+			printText("  key: passphrase, %s, %s form\n", kdfText(info.KDF), info.PassphraseForm)
+		} else {
+			printText("  key: %d stanzas, any one decrypts\n", len(info.Stanzas))
+		}
+		for _, st := range info.Stanzas {
+			switch {
+			case st.KDF != nil:
+				printText("    %s (%s)\n", st.Type, kdfText(st.KDF))
+			case st.WorkFactor > 0:
+				printText("    %s (N=2^%d)\n", st.Type, st.WorkFactor)
+			case st.Shares > 0:
+				printText("    %s (any %d of %d)\n", st.Type, st.Threshold, st.Shares)
+			default:
+				printText("    %s\n", st.Type)
+			}
+		}
+		printText("  metadata: %s\n  sparse: %s\n", yesNo(info.Metadata), yesNo(info.Sparse))
+		if info.Keyfile {
+			printText("  keyfile: required\n")
+		}
+
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+func kdfText(k *genc.KDFInfo) string {
+	return fmt.Sprintf("argon2id m=%dMiB t=%d p=%d", k.MemoryKiB/1024, k.Iterations, k.Threads)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	inspectCmd.MarkPersistentFlagRequired("file")
+	rootCmd.AddCommand(inspectCmd)
+}
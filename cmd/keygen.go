@@ -0,0 +1,146 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keygenOut     string
+	keygenFormat  string
+	keygenKeyfile string
+	keygenSign    bool
+)
+
+var keygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate an X25519 identity, a signing key or a keyfile",
+	Long: `Generate a new X25519 identity and print its recipient. Files encrypted to the
+recipient with "encrypt -r" can only be decrypted with the identity, "decrypt -i".
+With --format age the keys are written as age-keygen writes them, both forms
+work with go-enc. With --keyfile a random keyfile for encrypt --keyfile is
+written instead, with --sign an Ed25519 key for encrypt --sign-with.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		_, err := genc.ParseFormat(keygenFormat)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		if keygenKeyfile != "" {
+			key, err := genc.GenerateKeyfile()
+			if err != nil {
+				return res.finish(os.Stdout, "", 0, err)
+			}
+			defer genc.Wipe(key)
+
+			if err := writeNewFile(keygenKeyfile, key); err != nil {
+				return res.finish(os.Stdout, "", 0, err)
+			}
+			printText("keyfile written: %s, keep a copy, files encrypted with it cannot be decrypted without it\n", keygenKeyfile)
+			return res.finish(os.Stdout, keygenKeyfile, int64(len(key)), nil)
+		}
+
+		created := time.Now().Format(time.RFC3339)
+		var pub, content string
+
+		if keygenSign {
+			key, err := genc.GenerateSigningKey()
+			if err != nil {
+				return res.finish(os.Stdout, "", 0, err)
+			}
+			pub = key.Public().String()
+			content = fmt.Sprintf("# created: %s\n# public key: %s\n%s\n", created, pub, key)
+		} else {
+			id, err := genc.GenerateX25519Identity()
+			if err != nil {
+				return res.finish(os.Stdout, "", 0, err)
+			}
+
+			pub = id.Recipient().String()
+			content = fmt.Sprintf("# created: %s\n# recipient: %s\n%s\n", created, pub, id)
+
+			if format, _ := genc.ParseFormat(keygenFormat); format == genc.FormatAge {
+				pub = id.Recipient().AgeString()
+				content = fmt.Sprintf("# created: %s\n# public key: %s\n%s\n", created, pub, id.AgeString())
+			}
+		}
+		res.Recipient = pub
+
+		if keygenOut == "" {
+			if outputFormat == "json" {
+				return res.finish(os.Stdout, "", 0, fmt.Errorf("keygen needs -o with --output json"))
+			}
+			fmt.Print(content)
+			return res.finish(os.Stdout, "-", 0, nil)
+		}
+
+		if err := writeNewFile(keygenOut, []byte(content)); err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		if outputFormat == "text" {
+			if keygenSign {
+				fmt.Fprintf(os.Stderr, "public key: %s\n", pub)
+			} else {
+				fmt.Fprintf(os.Stderr, "recipient: %s\n", pub)
+			}
+		}
+		return res.finish(os.Stdout, keygenOut, 0, nil)
+	},
+}
+
+// writeNewFile writes data to a new 0600 file at path. An existing file is
+// never replaced, whatever it protects would be lost.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			err = fmt.Errorf("%w: %s", genc.ErrExists, path)
+		}
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("%w: %w", genc.ErrCreateFile, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("%w: %w", genc.ErrCreateFile, err)
+	}
+	return nil
+}
+
+func init() {
+	keygenCmd.Flags().StringVarP(&keygenOut, "outpath", "o", "", "write the identity to this file instead of stdout")
+	keygenCmd.Flags().StringVar(&keygenFormat, "format", "genc", "key format: genc, or age for age1... keys")
+	keygenCmd.Flags().StringVar(&keygenKeyfile, "keyfile", "", "write a random 32 byte keyfile to this path instead of an identity")
+	keygenCmd.MarkFlagsMutuallyExclusive("keyfile", "outpath")
+	keygenCmd.Flags().BoolVar(&keygenSign, "sign", false, "generate an Ed25519 signing key instead, its public key verifies files signed with it")
+	keygenCmd.MarkFlagsMutuallyExclusive("keyfile", "format")
+	keygenCmd.MarkFlagsMutuallyExclusive("keyfile", "sign")
+	keygenCmd.MarkFlagsMutuallyExclusive("sign", "format")
+	rootCmd.AddCommand(keygenCmd)
+}
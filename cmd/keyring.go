@@ -0,0 +1,374 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/irrisdev/go-enc/internal"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	keysIdentity string
+	exportSecret bool
+
+	// keyring is opened at most once per run
+	keyring     *genc.Keyring
+	keyringPath string
+	keyringPass []byte
+)
+
+// keyInfo describes a key or group of the keyring, never its identity.
+type keyInfo struct {
+	Name      string   `json:"name"`
+	Recipient string   `json:"recipient,omitempty"`
+	Identity  bool     `json:"identity,omitempty"`
+	Members   []string `json:"members,omitempty"`
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage the keyring of named recipients and identities",
+	Long: `The keyring holds recipients under names, so "encrypt -r alice" or "-r @ops"
+for a group work, and identities, which decrypt picks up on its own. It is
+kept in $XDG_CONFIG_HOME/go-enc/keyring.genc, itself encrypted with a master
+passphrase, given with -p to these commands and prompted for otherwise.`,
+}
+
+var keysAddCmd = &cobra.Command{
+	Use:   "add NAME RECIPIENT | add NAME -i FILE | add @GROUP NAME...",
+	Short: "Add a recipient, an identity or group members",
+	Args:  cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case strings.HasPrefix(args[0], "@"):
+			if len(args) < 2 || keysIdentity != "" {
+				return errors.New("add @GROUP takes the names of its members")
+			}
+		case keysIdentity != "":
+			if len(args) != 1 {
+				return errors.New("add NAME -i FILE takes no recipient")
+			}
+		case len(args) != 2:
+			return errors.New("add NAME takes one recipient, or an identity with -i")
+		}
+		_, err := parseStrengthFlags()
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		var data []byte
+		if keysIdentity != "" {
+			var err error
+			if data, err = os.ReadFile(keysIdentity); err != nil {
+				return res.finish(os.Stdout, "", 0, fmt.Errorf("failed to read identity file: %w", err))
+			}
+			defer genc.Wipe(data)
+		}
+
+		err := updateKeyring(cmd, func(k *genc.Keyring) error {
+			if group, ok := strings.CutPrefix(args[0], "@"); ok {
+				return k.AddToGroup(group, args[1:])
+			}
+			if data != nil {
+				return k.AddIdentity(args[0], data)
+			}
+			return k.AddRecipient(args[0], args[1])
+		})
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		printText("added to the keyring: %s\n", args[0])
+		return res.finish(os.Stdout, keyringPath, 0, nil)
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys and groups of the keyring",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		k, err := openKeyring(false, true)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		for _, e := range k.Sorted() {
+			res.Keys = append(res.Keys, keyInfo{Name: e.Name, Recipient: e.Recipient, Identity: e.Identity != ""})
+			mark := ""
+			if e.Identity != "" {
+				mark = " (identity)"
+			}
+			printText("%s\t%s%s\n", e.Name, e.Recipient, mark)
+		}
+		for _, g := range sortedGroups(k) {
+			res.Keys = append(res.Keys, keyInfo{Name: "@" + g, Members: k.Groups[g]})
+			printText("@%s\t%s\n", g, strings.Join(k.Groups[g], ", "))
+		}
+
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+var keysRemoveCmd = &cobra.Command{
+	Use:   "remove NAME|@GROUP",
+	Short: "Remove a key, from its groups as well, or a group",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		err := updateKeyring(cmd, func(k *genc.Keyring) error {
+			return k.Remove(args[0])
+		})
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		printText("removed from the keyring: %s\n", args[0])
+		return res.finish(os.Stdout, keyringPath, 0, nil)
+	},
+}
+
+var keysExportCmd = &cobra.Command{
+	Use:   "export NAME|@GROUP",
+	Short: "Print the recipients of a key or group, or with --secret an identity",
+	Long: `Print the recipient of a key, or of every member of a group, in the form
+"encrypt -R" reads. With --secret the identity of a key is printed instead.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, "")
+
+		k, err := openKeyring(false, true)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+
+		if exportSecret {
+			e := k.Entry(args[0])
+			if e == nil || e.Identity == "" {
+				return res.finish(os.Stdout, "", 0, fmt.Errorf("%w: no identity named %q", genc.ErrKeyring, args[0]))
+			}
+			if outputFormat == "json" {
+				return res.finish(os.Stdout, "", 0, errors.New("export --secret does not support --output json"))
+			}
+			fmt.Print(strings.TrimRight(e.Identity, "\n") + "\n")
+			return res.finish(os.Stdout, "-", 0, nil)
+		}
+
+		names := []string{args[0]}
+		if group, ok := strings.CutPrefix(args[0], "@"); ok {
+			if _, err := k.Lookup(args[0]); err != nil {
+				return res.finish(os.Stdout, "", 0, err)
+			}
+			names = k.Groups[group]
+		}
+
+		for _, name := range names {
+			e := k.Entry(name)
+			if e == nil {
+				return res.finish(os.Stdout, "", 0, fmt.Errorf("%w: no key named %q", genc.ErrKeyring, name))
+			}
+			res.Keys = append(res.Keys, keyInfo{Name: e.Name, Recipient: e.Recipient})
+			printText("# %s\n%s\n", e.Name, e.Recipient)
+		}
+		return res.finish(os.Stdout, "-", 0, nil)
+	},
+}
+
+// keyringPassphrase returns the master passphrase, -p when usePass is set for
+// the keys commands, prompted for otherwise. A new keyring asks for it twice.
+func keyringPassphrase(usePass, confirm bool) ([]byte, error) {
+	if keyringPass != nil {
+		return keyringPass, nil
+	}
+	if usePass && len(passphrase) > 0 {
+		keyringPass = passphrase
+		return keyringPass, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, errors.New("keyring passphrase is required, run from a terminal")
+	}
+
+	pass, err := promptSecret(fd, "Keyring passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		again, err := promptSecret(fd, "Confirm keyring passphrase: ")
+		if err != nil {
+			genc.Wipe(pass)
+			return nil, err
+		}
+		defer genc.Wipe(again)
+
+		if !bytes.Equal(pass, again) {
+			genc.Wipe(pass)
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+
+	keyringPass = pass
+	genc.LockMemory(keyringPass)
+	return keyringPass, nil
+}
+
+// openKeyring decrypts the keyring, which with create may not exist yet.
+// usePass is set for the keys commands, whose -p is the master passphrase.
+func openKeyring(create, usePass bool) (*genc.Keyring, error) {
+	if keyring != nil {
+		return keyring, nil
+	}
+
+	path, pass, err := keyringKey(create, usePass)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := genc.OpenKeyring(pass, path)
+	if err != nil {
+		return nil, fmt.Errorf("keyring %s: %w", path, err)
+	}
+	keyring = k
+	return k, nil
+}
+
+// keyringKey returns the path of the keyring and its master passphrase,
+// held to the policy of encrypt when the keyring is yet to be created.
+func keyringKey(create, usePass bool) (string, []byte, error) {
+	path, err := genc.KeyringPath()
+	if err != nil {
+		return "", nil, err
+	}
+	keyringPath = path
+
+	exists := true
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if !create {
+			return "", nil, fmt.Errorf("%w: %s does not exist, add keys with go-enc keys add", genc.ErrKeyring, path)
+		}
+		exists = false
+	}
+
+	pass, err := keyringPassphrase(usePass, !exists)
+	if err != nil {
+		return "", nil, err
+	}
+	if !exists {
+		if n := utf8.RuneCount(pass); n < MinPassLen {
+			return "", nil, usageError{fmt.Errorf("keyring passphrase must be at least %d characters, got %d", MinPassLen, n)}
+		}
+		strength, err := parseStrengthFlags()
+		if err != nil {
+			return "", nil, err
+		}
+		if err := checkStrength(pass, strength); err != nil {
+			return "", nil, usageError{fmt.Errorf("keyring %w", err)}
+		}
+	}
+	return path, pass, nil
+}
+
+// updateKeyring applies change to the keyring and saves it, holding its lock
+// throughout.
+func updateKeyring(cmd *cobra.Command, change func(k *genc.Keyring) error) error {
+	path, pass, err := keyringKey(true, true)
+	if err != nil {
+		return err
+	}
+	return genc.UpdateKeyring(cmd.Context(), pass, path, change)
+}
+
+// keyringRecipients looks up a name or @group given to --recipient.
+func keyringRecipients(name string) ([]genc.Recipient, error) {
+	k, err := openKeyring(false, false)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %s: %w", name, err)
+	}
+	return k.Lookup(name)
+}
+
+// keyringIdentities returns the identities of the keyring for path, when it
+// is encrypted to public keys and a keyring exists.
+func keyringIdentities(path string) ([]genc.Identity, error) {
+	kp, err := genc.KeyringPath()
+	if err != nil || !internal.FileExists(kp) || !hasPublicKeyStanzas(path) {
+		return nil, nil
+	}
+
+	k, err := openKeyring(false, false)
+	if err != nil {
+		return nil, err
+	}
+	return k.Identities(func(name string) func() ([]byte, error) {
+		return sshPassphrase("keyring key " + name)
+	})
+}
+
+// hasPublicKeyStanzas reports whether the file at path can be decrypted by
+// an identity.
+func hasPublicKeyStanzas(path string) bool {
+	in, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer in.Close()
+
+	info, err := genc.Inspect(in)
+	if err != nil {
+		return false
+	}
+	for _, st := range info.Stanzas {
+		switch st.Type {
+		case "passphrase", "scrypt", "shares":
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func sortedGroups(k *genc.Keyring) []string {
+	var groups []string
+	for g := range k.Groups {
+		groups = append(groups, g)
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+func init() {
+	keysAddCmd.Flags().StringVarP(&keysIdentity, "identity", "i", "", "add the identity in this file, or an ssh private key, along with its recipient")
+	addStrengthFlags(keysAddCmd)
+	keysExportCmd.Flags().BoolVar(&exportSecret, "secret", false, "print the identity of the key, it decrypts everything encrypted to it")
+	keysCmd.AddCommand(keysAddCmd, keysListCmd, keysRemoveCmd, keysExportCmd)
+	rootCmd.AddCommand(keysCmd)
+}
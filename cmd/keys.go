@@ -0,0 +1,288 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	recipientArgs  []string
+	recipientFiles []string
+	identityFiles  []string
+	withPass       bool
+	keyfilePath    string
+	shareArgs      []string
+	signKeyPath    string
+	verifyArgs     []string
+
+	// recipients and identities are parsed before the command runs
+	recipients []genc.Recipient
+	identities []genc.Identity
+
+	// keyfile is the digest of --keyfile
+	keyfile []byte
+
+	// signingKey and verifyKeys are loaded from --sign-with and --verify-with
+	signingKey *genc.SigningKey
+	verifyKeys []*genc.VerifyKey
+)
+
+// addRecipientFlags registers --recipient and --recipients-file for commands
+// that encrypt, what they do is described by use.
+func addRecipientFlags(cmd *cobra.Command, use string) {
+	cmd.Flags().StringArrayVarP(&recipientArgs, "recipient", "r", nil, use+" this public key, genc1..., age1... or ssh-ed25519/ssh-rsa, may be repeated")
+	cmd.Flags().StringArrayVarP(&recipientFiles, "recipients-file", "R", nil, use+" every public key in this file, such as authorized_keys, may be repeated")
+}
+
+// addIdentityFlag registers --identity for commands that decrypt.
+func addIdentityFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&identityFiles, "identity", "i", nil, "decrypt with the identities in this file, or an ssh private key, may be repeated")
+}
+
+// addShareFlag registers --share for commands that decrypt.
+func addShareFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&shareArgs, "share", nil, "a share from encrypt --split, or a file holding one, repeat for as many as needed")
+}
+
+// addVerifyFlag registers --verify-with for commands that decrypt.
+func addVerifyFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&verifyArgs, "verify-with", nil, "refuse the file unless its .sig is by this public key, or one in this file, may be repeated")
+}
+
+// addKeyfileFlag registers --keyfile for commands that encrypt or decrypt
+// with a passphrase.
+func addKeyfileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&keyfilePath, "keyfile", "", "keyfile the passphrase is combined with, both are needed to decrypt")
+}
+
+// loadKeyfile reads --keyfile, when given.
+func loadKeyfile() error {
+	if keyfilePath == "" {
+		return nil
+	}
+
+	f, err := os.Open(keyfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read keyfile: %w", err)
+	}
+	defer f.Close()
+
+	keyfile, err = genc.ReadKeyfile(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", keyfilePath, err)
+	}
+	return nil
+}
+
+// requireKey loads the identities from --identity and --share, or without
+// them makes sure there is a passphrase. A passphrase given with -p is tried
+// after the identities. When none of them are given, the agent is used if it
+// holds a key for the file, and files encrypted to public keys are otherwise
+// decrypted with the identities in the keyring.
+func requireKey() error {
+	if len(identityFiles) == 0 && len(shareArgs) == 0 {
+		if len(passphrase) == 0 {
+			if ids := agentIdentities(file); ids != nil {
+				identities = ids
+				return nil
+			}
+
+			// files encrypted to public keys try the keyring's identities
+			ids, err := keyringIdentities(file)
+			if err != nil {
+				return err
+			}
+			if len(ids) > 0 {
+				identities = ids
+				return nil
+			}
+		}
+		return requirePassphrase(false)
+	}
+
+	for _, path := range identityFiles {
+		ids, err := loadIdentities(path)
+		if err != nil {
+			return err
+		}
+		identities = append(identities, ids...)
+	}
+
+	if len(shareArgs) > 0 {
+		id, err := loadShares()
+		if err != nil {
+			return err
+		}
+		identities = append(identities, id)
+	}
+
+	return nil
+}
+
+// loadShares recovers the secret of a split from every --share, each a share
+// itself or the path of a share file.
+func loadShares() (*genc.ShareIdentity, error) {
+	var shares []*genc.Share
+	for _, arg := range shareArgs {
+		if strings.HasPrefix(strings.ToUpper(arg), "GENC-SHARE-1") {
+			sh, err := genc.ParseShare(arg)
+			if err != nil {
+				return nil, err
+			}
+			shares = append(shares, sh)
+			continue
+		}
+
+		f, err := os.Open(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read share file: %w", err)
+		}
+		sh, err := genc.ParseShares(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+		shares = append(shares, sh...)
+	}
+
+	return genc.NewShareIdentity(shares)
+}
+
+func loadIdentities(path string) ([]genc.Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity file: %w", err)
+	}
+	defer genc.Wipe(data)
+
+	ids, err := genc.ParseIdentityFile(data, sshPassphrase(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ids, nil
+}
+
+// sshPassphrase prompts for the passphrase of an encrypted ssh key, which
+// only happens once the file turns out to be encrypted to it.
+func sshPassphrase(path string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, fmt.Errorf("%s is encrypted, its passphrase can only be entered on a terminal", path)
+		}
+		return promptSecret(fd, fmt.Sprintf("Passphrase for %s: ", path))
+	}
+}
+
+// loadSigningKey reads the key file of --sign-with, when given.
+func loadSigningKey() error {
+	if signKeyPath == "" {
+		return nil
+	}
+
+	f, err := os.Open(signKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+	defer f.Close()
+
+	signingKey, err = genc.ReadSigningKey(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", signKeyPath, err)
+	}
+	return nil
+}
+
+// loadVerifyKeys parses every --verify-with, each a public key itself or
+// the path of a file of them.
+func loadVerifyKeys() error {
+	for _, arg := range verifyArgs {
+		if strings.HasPrefix(arg, "gencsign1") {
+			key, err := genc.ParseVerifyKey(arg)
+			if err != nil {
+				return err
+			}
+			verifyKeys = append(verifyKeys, key)
+			continue
+		}
+
+		f, err := os.Open(arg)
+		if err != nil {
+			return fmt.Errorf("failed to read public keys: %w", err)
+		}
+		keys, err := genc.ParseVerifyKeys(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", arg, err)
+		}
+		verifyKeys = append(verifyKeys, keys...)
+	}
+	return nil
+}
+
+// inputSignature reads the signature of the file at path when --verify-with
+// was given, it is checked while the file is decrypted.
+func inputSignature(path string) ([]byte, error) {
+	if len(verifyKeys) == 0 {
+		return nil, nil
+	}
+	return genc.ReadSignature(path)
+}
+
+// parseRecipients parses every --recipient and --recipients-file.
+func parseRecipients() error {
+	for _, arg := range recipientArgs {
+		// names and @groups from the keyring, a malformed key is reported
+		// as such rather than looked up
+		if !genc.IsRecipientKey(arg) && genc.IsKeyName(arg) {
+			rs, err := keyringRecipients(arg)
+			if err != nil {
+				return err
+			}
+			recipients = append(recipients, rs...)
+			continue
+		}
+
+		r, err := genc.ParseRecipient(arg)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, r)
+	}
+
+	for _, path := range recipientFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read recipients file: %w", err)
+		}
+		rs, err := genc.ParseRecipients(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		recipients = append(recipients, rs...)
+	}
+	return nil
+}
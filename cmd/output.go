@@ -0,0 +1,263 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes are part of the CLI contract, see README.
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+	ExitAuth    = 3
+	ExitCorrupt = 4
+	ExitIO      = 5
+	ExitExists  = 6
+	ExitLocked  = 7
+
+	// ExitInterrupted follows the shell convention of 128+SIGINT
+	ExitInterrupted = 130
+)
+
+var errorCodes = map[int]string{
+	ExitFailure: "failure",
+	ExitUsage:   "usage",
+	ExitAuth:    "auth",
+	ExitCorrupt: "corrupt",
+	ExitIO:      "io",
+	ExitExists:  "exists",
+	ExitLocked:  "locked",
+
+	ExitInterrupted: "interrupted",
+}
+
+var (
+	outputFormat string
+
+	// running is set once a command's RunE starts, errors before that are usage errors
+	running bool
+
+	// reported is set once a command has emitted its own result object
+	reported bool
+)
+
+// usageError marks errors caused by invalid invocation rather than by the operation itself.
+type usageError struct {
+	error
+}
+
+func (e usageError) Unwrap() error { return e.error }
+
+// skipped marks a file that was deliberately left alone by policy, it is
+// reported but is not an error.
+type skipped struct {
+	error
+}
+
+// result is the machine readable outcome of processing one file.
+type result struct {
+	Command    string          `json:"command"`
+	Input      string          `json:"input"`
+	Output     string          `json:"output,omitempty"`
+	Bytes      int64           `json:"bytes"`
+	Passphrase string          `json:"passphrase_form,omitempty"`
+	Recipient  string          `json:"recipient,omitempty"`
+	SignedBy   string          `json:"signed_by,omitempty"`
+	Keys       []keyInfo       `json:"keys,omitempty"`
+	AgentKeys  []genc.AgentKey `json:"agent_keys,omitempty"`
+	// Shares are the share files written by encrypt --split, or the shares
+	// themselves with --print-shares
+	Shares     []string       `json:"shares,omitempty"`
+	Info       *genc.FileInfo `json:"info,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Status     string         `json:"status"`
+	ErrorCode  string         `json:"error_code,omitempty"`
+	ExitCode   int            `json:"exit_code"`
+	Error      string         `json:"error,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
+
+	start time.Time
+}
+
+// resultWriter returns where results for cmd are written. Commands whose
+// stdout carries plaintext or a child's output report on stderr instead.
+func resultWriter(cmd *cobra.Command) io.Writer {
+	if cmd.Annotations["results"] == "stderr" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+func newResult(cmd *cobra.Command, input string) *result {
+	return &result{Command: cmd.Name(), Input: input, start: time.Now()}
+}
+
+// finish completes r with the outcome of the operation and, in json mode,
+// writes it to w. It returns err so callers can finish and return in one step.
+func (r *result) finish(w io.Writer, output string, n int64, err error) error {
+	r.Output = output
+	r.Bytes = n
+	r.DurationMs = time.Since(r.start).Milliseconds()
+	r.Status = "ok"
+
+	var skip skipped
+	if errors.As(err, &skip) {
+		r.Status = "skipped"
+		r.Reason = skip.Error()
+		err = nil
+	}
+
+	r.ExitCode = exitCode(err)
+
+	if err != nil {
+		r.Status = "error"
+		r.ErrorCode = errorCodes[r.ExitCode]
+		r.Error = err.Error()
+
+		// the status is the command's own, not one of go-enc's codes
+		var child childExit
+		if errors.As(err, &child) {
+			r.ErrorCode = "command"
+		}
+	}
+
+	if outputFormat == "json" {
+		json.NewEncoder(w).Encode(r)
+		reported = true
+	}
+
+	return err
+}
+
+// printText prints human readable messages, which are suppressed in json mode.
+func printText(format string, a ...any) {
+	if outputFormat == "text" {
+		fmt.Printf(format, a...)
+	}
+}
+
+// exitCode maps an error to one of the documented exit codes.
+func exitCode(err error) int {
+	var usage usageError
+	var pathErr *fs.PathError
+	var child childExit
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &child):
+		return child.status
+	case errors.Is(err, genc.ErrInterrupted):
+		return ExitInterrupted
+	case errors.As(err, &usage),
+		errors.Is(err, genc.ErrSymlink),
+		errors.Is(err, genc.ErrSpecialFile),
+		errors.Is(err, genc.ErrHardLinks),
+		errors.Is(err, genc.ErrFormat),
+		errors.Is(err, genc.ErrKeyfile),
+		errors.Is(err, genc.ErrSigningKey),
+		errors.Is(err, genc.ErrKeyring):
+		return ExitUsage
+	case errors.Is(err, genc.ErrAuth),
+		errors.Is(err, genc.ErrNoIdentity),
+		errors.Is(err, genc.ErrShare),
+		errors.Is(err, genc.ErrSignature):
+		return ExitAuth
+	case errors.Is(err, genc.ErrExists):
+		return ExitExists
+	case errors.Is(err, genc.ErrLocked):
+		return ExitLocked
+	case errors.Is(err, genc.ErrTruncated),
+		errors.Is(err, genc.ErrInvalidHeader),
+		errors.Is(err, genc.ErrKDFLimit),
+		errors.Is(err, genc.ErrDecryptChunk),
+		errors.Is(err, genc.ErrVerify):
+		return ExitCorrupt
+	case errors.Is(err, genc.ErrOpenFile),
+		errors.Is(err, genc.ErrCreateFile),
+		errors.Is(err, genc.ErrReadHeader),
+		errors.Is(err, genc.ErrWriteHeader),
+		errors.Is(err, genc.ErrReadChunk),
+		errors.Is(err, genc.ErrWriteChunk),
+		errors.Is(err, genc.ErrSyncEncFile),
+		errors.Is(err, genc.ErrSyncDecFile),
+		errors.Is(err, genc.ErrMetadata),
+		errors.Is(err, genc.ErrBakFile),
+		errors.Is(err, genc.ErrRemoveOrigin),
+		errors.Is(err, genc.ErrSign),
+		errors.Is(err, genc.ErrNoSpace),
+		errors.As(err, &pathErr):
+		return ExitIO
+	}
+
+	return ExitFailure
+}
+
+// reportError prints an error that no command reported and returns its exit code.
+func reportError(cmd *cobra.Command, err error) int {
+	code := exitCode(err)
+
+	// a failing command run by exec has said why itself
+	var child childExit
+
+	if outputFormat == "json" {
+		if !reported {
+			r := newResult(cmd, file)
+			r.finish(resultWriter(cmd), "", 0, err)
+		}
+	} else if !errors.As(err, &child) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+
+	return code
+}
+
+// markRunning wraps every command's RunE so that errors returned before it
+// starts are reported as usage errors, and so that interrupt signals cancel
+// the command's context instead of killing the process.
+func markRunning(c *cobra.Command) {
+	if run := c.RunE; run != nil {
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			running = true
+
+			// the invocation was valid, don't print usage for runtime errors
+			cmd.SilenceUsage = true
+
+			ctx, stop := notifyInterrupt(cmd.Context())
+			defer stop()
+			cmd.SetContext(ctx)
+
+			return run(cmd, args)
+		}
+	}
+
+	for _, sub := range c.Commands() {
+		markRunning(sub)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/irrisdev/go-enc/genc"
+)
+
+func TestExitCodeInterrupted(t *testing.T) {
+	interrupted := fmt.Errorf("%w: %w", genc.ErrInterrupted, context.Canceled)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "interrupted", err: interrupted, want: ExitInterrupted},
+		{name: "original kept", err: fmt.Errorf("%w: %w", genc.ErrRemoveOrigin, interrupted), want: ExitInterrupted},
+		{name: "edit discarded", err: errEditInterrupted, want: ExitInterrupted},
+		{name: "remove failed", err: fmt.Errorf("%w: busy", genc.ErrRemoveOrigin), want: ExitIO},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCode(tc.err); got != tc.want {
+				t.Errorf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
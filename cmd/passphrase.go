@@ -0,0 +1,144 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// secretValue is a flag value held as []byte so it can be wiped, and never
+// printed back in help output.
+type secretValue struct {
+	b *[]byte
+}
+
+func (s secretValue) Set(v string) error {
+	*s.b = []byte(v)
+	return nil
+}
+
+func (s secretValue) String() string { return "" }
+
+func (s secretValue) Type() string { return "string" }
+
+// requirePassphrase prompts for the passphrase on the terminal when -p was not
+// given. With confirm the passphrase has to be entered twice.
+func requirePassphrase(confirm bool) error {
+	if len(passphrase) > 0 {
+		return nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("passphrase is required, use -p or run from a terminal")
+	}
+
+	pass, err := promptSecret(fd, "Passphrase: ")
+	if err != nil {
+		return err
+	}
+
+	if confirm {
+		again, err := promptSecret(fd, "Confirm passphrase: ")
+		if err != nil {
+			genc.Wipe(pass)
+			return err
+		}
+		defer genc.Wipe(again)
+
+		if !bytes.Equal(pass, again) {
+			genc.Wipe(pass)
+			return errors.New("passphrases do not match")
+		}
+	}
+
+	passphrase = pass
+	genc.LockMemory(passphrase)
+
+	return nil
+}
+
+func promptSecret(fd int, prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	secret, err := term.ReadPassword(fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return secret, nil
+}
+
+// addStrengthFlags registers --min-strength and --weak-passphrase for commands
+// that set a new passphrase.
+func addStrengthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&minStrength, "min-strength", "good", "minimum passphrase strength: very-weak, weak, fair, good, strong or 0-4")
+	cmd.Flags().StringVar(&weakPass, "weak-passphrase", "reject", "what to do with a passphrase below --min-strength: reject or warn")
+}
+
+// parseStrengthFlags checks --min-strength and --weak-passphrase and returns
+// the minimum score.
+func parseStrengthFlags() (int, error) {
+	strength, err := genc.ParseStrength(minStrength)
+	if err != nil {
+		return 0, err
+	}
+	if weakPass != "reject" && weakPass != "warn" {
+		return 0, fmt.Errorf("invalid --weak-passphrase %q, must be reject or warn", weakPass)
+	}
+	return strength, nil
+}
+
+// checkStrength rejects a passphrase scoring below minScore, or only warns
+// about it with --weak-passphrase=warn. Parts of the file name count as words
+// an attacker would try first.
+func checkStrength(pass []byte, minScore int) error {
+	inputs := strings.FieldsFunc(filepath.Base(file), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	s := genc.CheckPassphrase(pass, inputs...)
+	if s.Score >= minScore {
+		return nil
+	}
+
+	msg := fmt.Sprintf("passphrase is %s, at least %s is required", genc.StrengthName(s.Score), genc.StrengthName(minScore))
+	if s.Warning != "" {
+		msg += ": " + s.Warning
+	}
+	if len(s.Suggestions) > 0 {
+		msg += " (" + strings.Join(s.Suggestions, "; ") + ")"
+	}
+
+	if weakPass == "warn" {
+		log.Printf("warning: %s\n", msg)
+		return nil
+	}
+	return errors.New(msg)
+}
@@ -18,16 +18,19 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 
+	"github.com/irrisdev/go-enc/genc"
 	"github.com/spf13/cobra"
 )
 
 const MinPassLen = 10
 
 var (
-	passphrase string
+	passphrase []byte
 	file       string
 )
 
@@ -36,19 +39,57 @@ var rootCmd = &cobra.Command{
 	Short:   "Encrypt and decrypt files",
 	Long:    `go-enc - A CLI tool for encrypting and decrypting files using AES-256`,
 	Version: "1.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat != "text" && outputFormat != "json" {
+			return fmt.Errorf("invalid output format %q, must be text or json", outputFormat)
+		}
+		if outputFormat == "json" {
+			cmd.SilenceUsage = true
+		}
+		return nil
+	},
+	SilenceErrors: true,
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// keep passphrases and keys out of core dumps, best effort
+	genc.HardenProcess()
+
+	markRunning(rootCmd)
+
+	cmd, err := rootCmd.ExecuteC()
+	genc.Wipe(passphrase)
+
+	if err != nil {
+		if !running {
+			err = usageError{err}
+		}
+		os.Exit(reportError(cmd, err))
+	}
+}
+
+// notifyInterrupt returns a context cancelled by the first interrupt signal so
+// partial output can be cleaned up. The default handling is restored at that
+// point, a second signal kills the process straight away.
+func notifyInterrupt(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
 	}
+	ctx, stop := signal.NotifyContext(parent, interruptSignals...)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx, stop
 }
 
 func init() {
 	// rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().StringVarP(&file, "file", "f", "", "path to file (required)")
-	rootCmd.PersistentFlags().StringVarP(&passphrase, "passphrase", "p", "", "encryption passphrase (required)")
+	rootCmd.PersistentFlags().VarP(secretValue{&passphrase}, "passphrase", "p", "encryption passphrase, prompted for when omitted")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "output format: text or json")
 
 	// rootCmd.MarkPersistentFlagRequired("file")
 	// rootCmd.MarkPersistentFlagRequired("passphrase")
@@ -0,0 +1,42 @@
+//go:build !unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import "os"
+
+// forwardedSignals are relayed from go-enc to child processes, Ctrl-C
+// already reaches every process on the console.
+var forwardedSignals []os.Signal
+
+// terminalSignals reach child processes directly, they are not forwarded.
+var terminalSignals = []os.Signal{
+	os.Interrupt,
+}
+
+// interruptSignals cancel the running command, see notifyInterrupt.
+var interruptSignals = []os.Signal{
+	os.Interrupt,
+}
+
+// foreground reports whether terminalSignals reach child processes without
+// go-enc, Ctrl-C goes to every process on the console.
+func foreground() bool {
+	return true
+}
@@ -0,0 +1,70 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// forwardedSignals are relayed from go-enc to child processes, they are
+// usually sent to go-enc's pid alone.
+var forwardedSignals = []os.Signal{
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// terminalSignals are sent by the terminal to the whole foreground process
+// group, so child processes get them directly. They are only forwarded when
+// go-enc is not in the foreground, see foreground.
+var terminalSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+}
+
+// interruptSignals cancel the running command, see notifyInterrupt.
+var interruptSignals = []os.Signal{
+	syscall.SIGINT,
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+}
+
+// foreground reports whether go-enc is in the foreground process group of
+// its controlling terminal, which sends terminalSignals to that whole group.
+// A signal sent to go-enc alone is then missed, a signal from the terminal
+// would otherwise reach the child twice.
+func foreground() bool {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	defer tty.Close()
+
+	fg, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	if err != nil {
+		return false
+	}
+	pgrp, err := unix.Getpgid(0)
+	return err == nil && fg == pgrp
+}
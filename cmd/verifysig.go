@@ -0,0 +1,64 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/irrisdev/go-enc/genc"
+	"github.com/spf13/cobra"
+)
+
+var verifySigCmd = &cobra.Command{
+	Use:   "verify-sig",
+	Short: "Check the signature of an encrypted file",
+	Long: `Check that the .sig beside a file, written by encrypt --sign-with, is a valid
+signature of the file by one of the trusted --verify-with keys. Nothing is
+decrypted, no passphrase is needed.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(verifyArgs) == 0 {
+			return errors.New("verify-sig needs at least one --verify-with")
+		}
+		return loadVerifyKeys()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		res := newResult(cmd, file)
+
+		in, err := os.Open(file)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, fmt.Errorf("%w: %w", genc.ErrOpenFile, err))
+		}
+		defer in.Close()
+
+		key, err := genc.VerifyFile(in, file, verifyKeys)
+		if err != nil {
+			return res.finish(os.Stdout, "", 0, err)
+		}
+		res.SignedBy = key.String()
+
+		printText("good signature by %s: %s\n", key, file)
+		return res.finish(os.Stdout, "", 0, nil)
+	},
+}
+
+func init() {
+	verifySigCmd.MarkPersistentFlagRequired("file")
+	addVerifyFlag(verifySigCmd)
+	rootCmd.AddCommand(verifySigCmd)
+}
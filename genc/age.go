@@ -0,0 +1,732 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/irrisdev/go-enc/internal"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// The age v1 format, https://age-encryption.org/v1: a text header of
+// stanzas each wrapping a 16 byte file key, closed by an HMAC, followed by a
+// 16 byte nonce and a ChaCha20-Poly1305 STREAM of 64 KiB chunks. age has no
+// room for metadata or sparse chunks.
+
+const (
+	ageIntro       = "age-encryption.org/v1\n"
+	ageFileKeySize = 16
+	ageChunkSize   = 64 * 1024
+	ageNonceSize   = 16
+
+	// bounds on untrusted header input
+	ageMaxLine     = 4096
+	ageMaxStanzas  = 1024
+	ageColumnWidth = 64
+)
+
+var ageBase64 = base64.RawStdEncoding.Strict()
+
+// ageStanza is one "-> type args..." header entry and its body.
+type ageStanza struct {
+	typ  string
+	args []string
+	body []byte
+}
+
+// ageHeader is a parsed header, raw holds its text up to and including the
+// "---" the MAC is computed over.
+type ageHeader struct {
+	stanzas []*ageStanza
+	mac     []byte
+	raw     []byte
+}
+
+// ageWrapper is implemented by recipients that can be used in age files.
+type ageWrapper interface {
+	wrapAge(fileKey []byte) (*ageStanza, error)
+}
+
+// ageUnwrapper is implemented by identities that can open age stanzas.
+type ageUnwrapper interface {
+	unwrapAge(st *ageStanza) ([]byte, error)
+}
+
+// isAge reports whether r starts with an age header.
+func isAge(r *bufio.Reader) bool {
+	intro, _ := r.Peek(len(ageIntro))
+	return string(intro) == ageIntro
+}
+
+func (st *ageStanza) marshal(b *bytes.Buffer) {
+	b.WriteString("-> ")
+	b.WriteString(strings.Join(append([]string{st.typ}, st.args...), " "))
+	b.WriteByte('\n')
+
+	// the last line is always shorter than a full one, even when empty
+	enc := ageBase64.EncodeToString(st.body)
+	for len(enc) >= ageColumnWidth {
+		b.WriteString(enc[:ageColumnWidth])
+		b.WriteByte('\n')
+		enc = enc[ageColumnWidth:]
+	}
+	b.WriteString(enc)
+	b.WriteByte('\n')
+}
+
+// marshalAgeHeader encodes stanzas and the header MAC keyed by fileKey.
+func marshalAgeHeader(stanzas []*ageStanza, fileKey []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(ageIntro)
+	for _, st := range stanzas {
+		st.marshal(&b)
+	}
+	b.WriteString("---")
+
+	mac, err := ageHeaderMAC(fileKey, b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(ageBase64.EncodeToString(mac))
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
+
+func ageHeaderMAC(fileKey, raw []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, fileKey, nil, "header", 32)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(key)
+
+	h := hmac.New(sha256.New, key)
+	h.Write(raw)
+	return h.Sum(nil), nil
+}
+
+// readAgeLine reads one line without its newline, refusing overlong lines.
+func readAgeLine(r *bufio.Reader, raw *bytes.Buffer) (string, error) {
+	var line []byte
+	for {
+		part, err := r.ReadSlice('\n')
+		line = append(line, part...)
+		if len(line) > ageMaxLine {
+			return "", fmt.Errorf("%w: header line too long", ErrInvalidHeader)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			return "", fmt.Errorf("%w: %w", ErrReadHeader, ErrTruncated)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrReadHeader, err)
+		}
+		break
+	}
+
+	raw.Write(line)
+	return string(line[:len(line)-1]), nil
+}
+
+// validAgeArg reports whether s is a non-empty run of visible ASCII.
+func validAgeArg(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAgeHeader reads an age header. The MAC is only checked once the file
+// key is known.
+func parseAgeHeader(r *bufio.Reader) (*ageHeader, error) {
+	var raw bytes.Buffer
+	h := &ageHeader{}
+
+	intro, err := readAgeLine(r, &raw)
+	if err != nil {
+		return nil, err
+	}
+	if intro+"\n" != ageIntro {
+		return nil, fmt.Errorf("%w: unsupported age version %q", ErrInvalidHeader, intro)
+	}
+
+	for {
+		line, err := readAgeLine(r, &raw)
+		if err != nil {
+			return nil, err
+		}
+
+		if mac, ok := strings.CutPrefix(line, "--- "); ok {
+			h.mac, err = ageBase64.DecodeString(mac)
+			if err != nil || len(h.mac) != sha256.Size {
+				return nil, fmt.Errorf("%w: malformed header MAC", ErrInvalidHeader)
+			}
+			// the MAC covers the header up to and including "---"
+			h.raw = raw.Bytes()[:raw.Len()-len(line)+3-1]
+			break
+		}
+
+		rest, ok := strings.CutPrefix(line, "-> ")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed age header line", ErrInvalidHeader)
+		}
+
+		args := strings.Split(rest, " ")
+		for _, a := range args {
+			if !validAgeArg(a) {
+				return nil, fmt.Errorf("%w: malformed stanza arguments", ErrInvalidHeader)
+			}
+		}
+
+		st := &ageStanza{typ: args[0], args: args[1:]}
+		for {
+			line, err := readAgeLine(r, &raw)
+			if err != nil {
+				return nil, err
+			}
+			if len(line) > ageColumnWidth {
+				return nil, fmt.Errorf("%w: stanza body line too long", ErrInvalidHeader)
+			}
+			b, err := ageBase64.DecodeString(line)
+			if err != nil {
+				return nil, fmt.Errorf("%w: malformed stanza body", ErrInvalidHeader)
+			}
+			st.body = append(st.body, b...)
+			if len(line) < ageColumnWidth {
+				break
+			}
+		}
+
+		h.stanzas = append(h.stanzas, st)
+		if len(h.stanzas) > ageMaxStanzas {
+			return nil, fmt.Errorf("%w: too many stanzas", ErrInvalidHeader)
+		}
+	}
+
+	return h, nil
+}
+
+// ageWriter writes age files for a file key wrapped for its recipients.
+type ageWriter struct {
+	fileKey []byte
+	header  []byte
+}
+
+// newAgeWriter wraps a random file key for recipients, or for pass alone.
+func newAgeWriter(pass []byte, recipients []Recipient) (*ageWriter, error) {
+	if len(recipients) > 0 && len(pass) > 0 {
+		return nil, fmt.Errorf("%w: age files cannot mix a passphrase with recipients", ErrRecipient)
+	}
+	if len(recipients) == 0 {
+		recipients = []Recipient{NewPassphraseRecipient(pass)}
+	}
+
+	fileKey := make([]byte, ageFileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+
+	var stanzas []*ageStanza
+	for _, r := range recipients {
+		w, ok := r.(ageWrapper)
+		if !ok {
+			internal.Wipe(fileKey)
+			return nil, fmt.Errorf("%w: %s cannot be used in age files", ErrRecipient, r)
+		}
+		st, err := w.wrapAge(fileKey)
+		if err != nil {
+			internal.Wipe(fileKey)
+			return nil, fmt.Errorf("%w %s: %w", ErrRecipient, r, err)
+		}
+		stanzas = append(stanzas, st)
+	}
+
+	header, err := marshalAgeHeader(stanzas, fileKey)
+	if err != nil {
+		internal.Wipe(fileKey)
+		return nil, err
+	}
+
+	return &ageWriter{fileKey: fileKey, header: header}, nil
+}
+
+func (a *ageWriter) key() []byte {
+	return a.fileKey
+}
+
+// size is exact, age files hold no metadata.
+func (a *ageWriter) size(info fs.FileInfo, md *Metadata) int64 {
+	plain := info.Size()
+	chunks := max((plain+ageChunkSize-1)/ageChunkSize, 1)
+	return int64(len(a.header)) + ageNonceSize + plain + chunks*chacha20poly1305.Overhead
+}
+
+// write ignores md, age has nowhere to store it.
+func (a *ageWriter) write(r io.Reader, w io.Writer, md *Metadata) error {
+	writer := bufio.NewWriterSize(w, internal.RWSize)
+
+	if _, err := writer.Write(a.header); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteHeader, err)
+	}
+
+	if err := encryptAgePayload(a.fileKey, bufio.NewReaderSize(r, internal.RWSize), writer); err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	return nil
+}
+
+// agePayloadAEAD derives the payload key from the file key and nonce.
+func agePayloadAEAD(fileKey, nonce []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, fileKey, nonce, "payload", chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(key)
+
+	return chacha20poly1305.New(key)
+}
+
+// ageChunkNonce is the chunk counter as an 11 byte big endian number
+// followed by the last chunk flag.
+func ageChunkNonce(nonce []byte, counter uint64, last bool) {
+	clear(nonce)
+	for i := 10; i >= 3; i-- {
+		nonce[i] = byte(counter)
+		counter >>= 8
+	}
+	if last {
+		nonce[11] = 1
+	}
+}
+
+func encryptAgePayload(fileKey []byte, reader *bufio.Reader, w io.Writer) error {
+	streamNonce := make([]byte, ageNonceSize)
+	if _, err := rand.Read(streamNonce); err != nil {
+		return fmt.Errorf("%w: %w", ErrNewNonce, err)
+	}
+	if _, err := w.Write(streamNonce); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+
+	aead, err := agePayloadAEAD(fileKey, streamNonce)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, ageChunkSize)
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: %w", ErrReadChunk, err)
+		}
+
+		// a full chunk is the last one when nothing follows it
+		last := err != nil
+		if !last {
+			if _, err := reader.Peek(1); err == io.EOF {
+				last = true
+			}
+		}
+
+		ageChunkNonce(nonce, counter, last)
+		if _, err := w.Write(aead.Seal(nil, nonce, buf[:n], nil)); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// verify decrypts an age file written by a.
+func (a *ageWriter) verify(reader *bufio.Reader, w io.Writer) error {
+	header, err := parseAgeHeader(reader)
+	if err != nil {
+		return err
+	}
+	if err := checkAgeMAC(header, a.fileKey); err != nil {
+		return err
+	}
+	return decryptAgePayload(a.fileKey, reader, w)
+}
+
+func decryptAgePayload(fileKey []byte, reader *bufio.Reader, w io.Writer) error {
+	streamNonce := make([]byte, ageNonceSize)
+	if err := readFull(reader, streamNonce); err != nil {
+		return fmt.Errorf("%w: %w", ErrReadChunk, err)
+	}
+
+	aead, err := agePayloadAEAD(fileKey, streamNonce)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriterSize(w, internal.RWSize)
+	buf := make([]byte, ageChunkSize+chacha20poly1305.Overhead)
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, buf)
+		if err == io.EOF {
+			// the previous chunk was not marked last
+			return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: %w", ErrReadChunk, err)
+		}
+
+		last := err == io.ErrUnexpectedEOF
+		if !last {
+			if _, err := reader.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return fmt.Errorf("%w: %w", ErrReadChunk, err)
+			}
+		}
+
+		if n < chacha20poly1305.Overhead {
+			return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+		}
+
+		ageChunkNonce(nonce, counter, last)
+		plaintext, err := aead.Open(buf[:0], nonce, buf[:n], nil)
+		if err != nil {
+			return fmt.Errorf("%w %d: %w", ErrDecryptChunk, counter, err)
+		}
+		if last && len(plaintext) == 0 && counter > 0 {
+			return fmt.Errorf("%w %d: empty last chunk", ErrDecryptChunk, counter)
+		}
+
+		if _, err := writer.Write(plaintext); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+		}
+
+		if last {
+			break
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	return nil
+}
+
+// decryptAge opens the header of an age file with the first of
+// o.Identities, or lastly pass, that matches one of its stanzas and decrypts
+// the payload. age files hold no metadata.
+func (o Options) decryptAge(pass []byte, reader *bufio.Reader, w io.Writer) error {
+	header, err := parseAgeHeader(reader)
+	if err != nil {
+		return err
+	}
+
+	// a passphrase must be the only way into a file, age refuses anything else
+	for _, st := range header.stanzas {
+		if st.typ == "scrypt" && len(header.stanzas) != 1 {
+			return fmt.Errorf("%w: scrypt stanza must be alone", ErrInvalidHeader)
+		}
+	}
+
+	ids := o.Identities
+	var passID *PassphraseIdentity
+	if len(pass) > 0 {
+		passID = &PassphraseIdentity{pass: pass, maxMemory: o.MaxKDFMemory}
+		ids = append(ids[:len(ids):len(ids)], passID)
+	}
+
+	fileKey, id, err := unwrapAgeFileKey(ids, header.stanzas)
+	if err != nil {
+		return err
+	}
+	defer internal.ProtectKey(fileKey)()
+
+	if err := checkAgeMAC(header, fileKey); err != nil {
+		return err
+	}
+
+	if err := decryptAgePayload(fileKey, reader, w); err != nil {
+		return err
+	}
+
+	if o.PassphraseMatch != nil && passID != nil && id == Identity(passID) {
+		*o.PassphraseMatch = PassphraseMatch{Form: passID.form, Used: true}
+	}
+	return nil
+}
+
+func checkAgeMAC(header *ageHeader, fileKey []byte) error {
+	mac, err := ageHeaderMAC(fileKey, header.raw)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, header.mac) {
+		return fmt.Errorf("%w: header MAC mismatch", ErrAuth)
+	}
+	return nil
+}
+
+func unwrapAgeFileKey(ids []Identity, stanzas []*ageStanza) ([]byte, Identity, error) {
+	for _, id := range ids {
+		u, ok := id.(ageUnwrapper)
+		if !ok {
+			continue
+		}
+		for _, st := range stanzas {
+			fileKey, err := u.unwrapAge(st)
+			if errors.Is(err, errWrongIdentity) {
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(fileKey) != ageFileKeySize {
+				internal.Wipe(fileKey)
+				return nil, nil, fmt.Errorf("%w: invalid file key length %d", ErrInvalidHeader, len(fileKey))
+			}
+			return fileKey, id, nil
+		}
+	}
+	return nil, nil, ErrNoIdentity
+}
+
+// ageSeal encrypts a file key with a one-time key, so the nonce is zero.
+func ageSeal(key, fileKey []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil), nil
+}
+
+// ageOpen reverses ageSeal, failing with errWrongIdentity on a mismatch.
+func ageOpen(key, wrapped []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), wrapped, nil)
+	if err != nil {
+		return nil, errWrongIdentity
+	}
+	return fileKey, nil
+}
+
+// X25519 stanzas: "-> X25519 <ephemeral share>", the body is the file key
+// sealed with a key derived from the shared secret.
+
+const ageX25519Label = "age-encryption.org/v1/X25519"
+
+func (r *X25519Recipient) wrapAge(fileKey []byte) (*ageStanza, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := eph.ECDH(r.pub)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(shared)
+
+	ephPub := eph.PublicKey().Bytes()
+	key, err := hkdf.Key(sha256.New, shared, x25519Salt(ephPub, r.pub), ageX25519Label, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(key)
+
+	wrapped, err := ageSeal(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ageStanza{typ: "X25519", args: []string{ageBase64.EncodeToString(ephPub)}, body: wrapped}, nil
+}
+
+func (i *X25519Identity) unwrapAge(st *ageStanza) ([]byte, error) {
+	if st.typ != "X25519" {
+		return nil, errWrongIdentity
+	}
+	if len(st.args) != 1 || len(st.body) != ageFileKeySize+chacha20poly1305.Overhead {
+		return nil, fmt.Errorf("%w: malformed X25519 stanza", ErrInvalidHeader)
+	}
+
+	share, err := ageBase64.DecodeString(st.args[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed X25519 stanza", ErrInvalidHeader)
+	}
+	eph, err := ecdh.X25519().NewPublicKey(share)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+
+	shared, err := i.priv.ECDH(eph)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+	defer internal.Wipe(shared)
+
+	key, err := hkdf.Key(sha256.New, shared, x25519Salt(share, i.priv.PublicKey()), ageX25519Label, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(key)
+
+	return ageOpen(key, st.body)
+}
+
+// scrypt stanzas: "-> scrypt <salt> <log2 work factor>", the body is the
+// file key sealed with the scrypt output.
+
+const (
+	ageScryptLabel = "age-encryption.org/v1/scrypt"
+	ageScryptLogN  = 18
+)
+
+func (r *PassphraseRecipient) wrapAge(fileKey []byte) (*ageStanza, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+
+	pass := internal.NormalizePass(r.pass, internal.PassNFC)
+	defer internal.Wipe(pass)
+
+	key, err := ageScryptKey(pass, salt, ageScryptLogN)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(key)
+
+	wrapped, err := ageSeal(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ageStanza{
+		typ:  "scrypt",
+		args: []string{ageBase64.EncodeToString(salt), strconv.Itoa(ageScryptLogN)},
+		body: wrapped,
+	}, nil
+}
+
+// unwrapAge tries the passphrase as typed and in NFC and NFD form, age
+// itself uses the bytes as typed.
+func (i *PassphraseIdentity) unwrapAge(st *ageStanza) ([]byte, error) {
+	if st.typ != "scrypt" {
+		return nil, errWrongIdentity
+	}
+	if len(st.args) != 2 || len(st.body) != ageFileKeySize+chacha20poly1305.Overhead {
+		return nil, fmt.Errorf("%w: malformed scrypt stanza", ErrInvalidHeader)
+	}
+
+	salt, err := ageBase64.DecodeString(st.args[0])
+	if err != nil || len(salt) != 16 {
+		return nil, fmt.Errorf("%w: malformed scrypt salt", ErrInvalidHeader)
+	}
+
+	logN, err := strconv.Atoi(st.args[1])
+	if err != nil || logN <= 0 || st.args[1] != strconv.Itoa(logN) {
+		return nil, fmt.Errorf("%w: malformed scrypt work factor", ErrInvalidHeader)
+	}
+
+	// scrypt with r=8 needs N KiB, bounded like argon2id memory
+	maxMemory := i.maxMemory
+	if maxMemory == 0 {
+		maxMemory = internal.DefaultMaxKDFMemory
+	}
+	if limit := bits.Len32(maxMemory) - 1; logN > limit {
+		return nil, fmt.Errorf("%w: scrypt work factor %d exceeds the limit of %d", ErrKDFLimit, logN, limit)
+	}
+
+	var tried [][]byte
+	defer func() {
+		for _, t := range tried {
+			internal.Wipe(t)
+		}
+	}()
+
+	for _, form := range []PassphraseForm{FormRaw, FormNFC, FormNFD} {
+		pass := internal.NormalizePass(i.pass, form)
+		if containsBytes(tried, pass) {
+			internal.Wipe(pass)
+			continue
+		}
+		tried = append(tried, pass)
+
+		key, err := ageScryptKey(pass, salt, logN)
+		if err != nil {
+			return nil, err
+		}
+		fileKey, err := ageOpen(key, st.body)
+		internal.Wipe(key)
+		if err == nil {
+			i.form = form
+			return fileKey, nil
+		}
+	}
+
+	return nil, errWrongIdentity
+}
+
+func containsBytes(list [][]byte, b []byte) bool {
+	for _, l := range list {
+		if bytes.Equal(l, b) {
+			return true
+		}
+	}
+	return false
+}
+
+func ageScryptKey(pass, salt []byte, logN int) ([]byte, error) {
+	return scrypt.Key(pass, append([]byte(ageScryptLabel), salt...), 1<<logN, 8, 1, chacha20poly1305.KeySize)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ageVector is a test vector of the age spec, from c2sp.org/CCTV/age: a
+// header of "key: value" lines, an empty line, then the age file.
+type ageVector struct {
+	expect     string
+	payload    string
+	identities []Identity
+	pass       []byte
+	file       []byte
+}
+
+func readAgeVector(t *testing.T, path string) ageVector {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, file, ok := bytes.Cut(data, []byte("\n\n"))
+	if !ok {
+		t.Fatalf("%s: no empty line after the header", path)
+	}
+
+	v := ageVector{file: file}
+	for _, line := range strings.Split(string(head), "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "expect":
+			v.expect = value
+		case "payload":
+			v.payload = value
+		case "identity":
+			id, err := ParseX25519Identity(value)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			v.identities = append(v.identities, id)
+		case "passphrase":
+			v.pass = []byte(value)
+		}
+	}
+	return v
+}
+
+func TestAgeVectors(t *testing.T) {
+	paths, err := filepath.Glob("testdata/age/*")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no vectors: %v", err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			v := readAgeVector(t, path)
+
+			var out bytes.Buffer
+			err := DecryptStream(v.pass, bytes.NewReader(v.file), &out, Options{Identities: v.identities})
+
+			switch v.expect {
+			case "success":
+				if err != nil {
+					t.Fatalf("DecryptStream: %v", err)
+				}
+				sum := sha256.Sum256(out.Bytes())
+				if hex.EncodeToString(sum[:]) != v.payload {
+					t.Fatalf("payload sha256 %x, want %s", sum, v.payload)
+				}
+			case "no match":
+				if !errors.Is(err, ErrNoIdentity) {
+					t.Fatalf("got %v, want %v", err, ErrNoIdentity)
+				}
+			case "header failure":
+				if !errors.Is(err, ErrInvalidHeader) && !errors.Is(err, ErrKDFLimit) {
+					t.Fatalf("got %v, want %v", err, ErrInvalidHeader)
+				}
+			default:
+				t.Fatalf("unknown expectation %q", v.expect)
+			}
+			if err != nil && out.Len() != 0 {
+				t.Fatalf("%d bytes written by a failed decryption", out.Len())
+			}
+		})
+	}
+}
+
+func TestAgeScryptMustBeAlone(t *testing.T) {
+	for _, name := range []string{"scrypt_and_x25519", "scrypt_double"} {
+		t.Run(name, func(t *testing.T) {
+			v := readAgeVector(t, filepath.Join("testdata/age", name))
+			err := DecryptStream(v.pass, bytes.NewReader(v.file), &bytes.Buffer{}, Options{Identities: v.identities})
+			if !errors.Is(err, ErrInvalidHeader) || !strings.Contains(err.Error(), "alone") {
+				t.Fatalf("got %v, want %v", err, ErrInvalidHeader)
+			}
+		})
+	}
+}
+
+func TestAgeScryptWorkFactorLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		vector    string
+		maxMemory uint32
+		wantErr   error
+	}{
+		// scrypt with r=8 needs 1<<logN KiB, the vector's logN is 10
+		{name: "at the limit", vector: "scrypt", maxMemory: 1 << 10},
+		{name: "over the limit", vector: "scrypt", maxMemory: 1<<10 - 1, wantErr: ErrKDFLimit},
+		{name: "default limit", vector: "scrypt_work_factor_23", wantErr: ErrKDFLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := readAgeVector(t, filepath.Join("testdata/age", tt.vector))
+
+			start := time.Now()
+			err := DecryptStream(v.pass, bytes.NewReader(v.file), &bytes.Buffer{}, Options{MaxKDFMemory: tt.maxMemory})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// refused before scrypt runs
+			if tt.wantErr != nil && time.Since(start) > time.Second {
+				t.Fatalf("took %s to refuse", time.Since(start))
+			}
+		})
+	}
+}
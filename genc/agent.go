@@ -0,0 +1,823 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// AgentSockEnv names the environment variable holding the socket of a
+// running agent.
+const AgentSockEnv = "GO_ENC_AGENT_SOCK"
+
+// AgentNoExpiry as the ttl of AddIdentity or AddPassphrase holds the key until
+// it is removed, a zero ttl holds it for the agent's TTL.
+const AgentNoExpiry time.Duration = -1
+
+const (
+	// agentMaxMessage bounds a request or response, identity files included
+	agentMaxMessage = 1 << 20
+
+	// agentTimeout covers a whole exchange, key derivation included
+	agentTimeout = 2 * time.Minute
+
+	// agentMaxDerived bounds the derived keys cached per passphrase
+	agentMaxDerived = 256
+)
+
+// errAgentPassphrase is returned when an ssh key added to the agent is
+// encrypted and no passphrase came with it.
+var errAgentPassphrase = errors.New("ssh key is encrypted")
+
+// errAgentLocked refuses every request but unlock and stop while the agent
+// is locked.
+var errAgentLocked = errors.New("agent is locked")
+
+// response codes the client maps back to errors
+const (
+	agentCodeAuth       = "auth"
+	agentCodeLocked     = "locked"
+	agentCodeWrong      = "wrong"
+	agentCodePassphrase = "passphrase"
+)
+
+type agentRequest struct {
+	Op         string          `json:"op"`
+	Name       string          `json:"name,omitempty"`
+	Identity   []byte          `json:"identity,omitempty"`
+	Passphrase []byte          `json:"passphrase,omitempty"`
+	TTL        time.Duration   `json:"ttl,omitempty"`
+	Stanza     internal.Stanza `json:"stanza,omitzero"`
+	Header     agentHeader     `json:"header,omitzero"`
+}
+
+// agentHeader is what the agent needs of a passphrase file's header to
+// derive its key.
+type agentHeader struct {
+	Version int              `json:"version"`
+	Salt    []byte           `json:"salt"`
+	KDF     []byte           `json:"kdf"`
+	Forms   []PassphraseForm `json:"forms"`
+	Keyfile []byte           `json:"keyfile,omitempty"`
+}
+
+type agentResponse struct {
+	Error   string         `json:"error,omitempty"`
+	Code    string         `json:"code,omitempty"`
+	Key     []byte         `json:"key,omitempty"`
+	Derived []agentDerived `json:"derived,omitempty"`
+	Keys    []AgentKey     `json:"keys,omitempty"`
+}
+
+// agentDerived is a file key derived from a held passphrase in one form.
+type agentDerived struct {
+	Form PassphraseForm `json:"form"`
+	Key  []byte         `json:"key"`
+}
+
+// AgentKey describes a key held by an agent.
+type AgentKey struct {
+	Name string `json:"name"`
+	// Passphrase is set for passphrases, identities are held otherwise
+	Passphrase bool `json:"passphrase,omitempty"`
+	// Expires is zero for keys held until removed
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// Agent holds unlocked identities and passphrases for other go-enc processes,
+// which send it the stanzas of their files to unwrap, so the keys never leave
+// it. Keys are mlocked where permitted and wiped once they expire, are
+// removed or the agent stops.
+type Agent struct {
+	// TTL is how long keys added without one are held, zero holds them until
+	// they are removed.
+	TTL time.Duration
+
+	// MaxKDFMemory is the most argon2id memory, in KiB, a file may ask for,
+	// DefaultMaxKDFMemory when zero.
+	MaxKDFMemory uint32
+
+	// mu guards the fields below and the keys, it is only held to read or
+	// change them, never while a key is derived or a stanza unwrapped
+	mu   sync.Mutex
+	keys []*agentKey
+	stop context.CancelFunc
+
+	// lock is derived from the lock passphrase while the agent is locked
+	lock     []byte
+	lockSalt []byte
+}
+
+type agentKey struct {
+	name string
+	// ids is nil once the key is dropped
+	ids     []Identity
+	data    []byte
+	pass    []byte
+	expires time.Time
+	timer   *time.Timer
+
+	// derived caches keys derived from pass, by salt and parameters
+	derived     map[string][]byte
+	wipeDerived []func()
+	cleanup     []func()
+}
+
+// AgentSocketPath returns the socket named by GO_ENC_AGENT_SOCK, or else
+// go-enc/agent.sock in $XDG_RUNTIME_DIR or a per-user temp directory.
+func AgentSocketPath() string {
+	if path := os.Getenv(AgentSockEnv); path != "" {
+		return path
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "go-enc", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("go-enc-%d", os.Getuid()), "agent.sock")
+}
+
+// ListenAgent listens on a 0600 socket at path, in a directory created 0700
+// and refused when other users can reach into it. A socket left behind by an
+// agent that is gone is replaced.
+func ListenAgent(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAgent, err)
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAgent, err)
+	}
+	if !info.IsDir() || info.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("%w: %s must be a directory only its owner can use", ErrAgent, dir)
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%w: an agent is already listening on %s", ErrAgent, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAgent, err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAgent, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("%w: %w", ErrAgent, err)
+	}
+	return l, nil
+}
+
+// Serve answers requests on l until ctx is cancelled or a client stops the
+// agent, then closes l and wipes every key.
+func (a *Agent) Serve(ctx context.Context, l net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	a.mu.Lock()
+	a.stop = cancel
+	a.mu.Unlock()
+	defer a.removeAll()
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("%w: %w", ErrAgent, err)
+		}
+		go a.handle(conn)
+	}
+}
+
+// handle answers the single request of a connection.
+func (a *Agent) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// the socket is 0600, the peer is checked as well where the platform
+	// tells who it is, root is let in like by ssh-agent
+	if uid, err := internal.PeerUID(conn); err == nil && uid != 0 && uid != os.Getuid() {
+		return
+	}
+	conn.SetDeadline(time.Now().Add(agentTimeout))
+
+	var req agentRequest
+	if err := json.NewDecoder(io.LimitReader(conn, agentMaxMessage)).Decode(&req); err != nil {
+		return
+	}
+	defer internal.Wipe(req.Identity)
+	defer internal.Wipe(req.Passphrase)
+
+	resp := a.do(&req)
+	defer internal.Wipe(resp.Key)
+	for _, d := range resp.Derived {
+		defer internal.Wipe(d.Key)
+	}
+
+	json.NewEncoder(conn).Encode(resp)
+}
+
+func (a *Agent) do(req *agentRequest) agentResponse {
+	var resp agentResponse
+	var err error
+	switch req.Op {
+	case "add":
+		err = a.add(req)
+	case "list":
+		resp.Keys, err = a.list()
+	case "remove":
+		err = a.remove(req.Name)
+	case "lock":
+		err = a.lockWith(req.Passphrase)
+	case "unlock":
+		err = a.unlockWith(req.Passphrase)
+	case "unwrap":
+		resp.Key, err = a.unwrap(req.Stanza)
+	case "derive":
+		resp.Derived, err = a.derive(req.Header)
+	case "stop":
+		a.mu.Lock()
+		if a.stop != nil {
+			a.stop()
+		}
+		a.mu.Unlock()
+	default:
+		err = fmt.Errorf("unknown request %q", req.Op)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	return resp
+}
+
+// errorResponse carries err back to the client, with a code for the errors
+// it acts on.
+func errorResponse(err error) agentResponse {
+	resp := agentResponse{Error: err.Error()}
+	switch {
+	case errors.Is(err, errAgentLocked):
+		resp.Code = agentCodeLocked
+	case errors.Is(err, errWrongIdentity), errors.Is(err, ErrNoIdentity):
+		resp.Code = agentCodeWrong
+	case errors.Is(err, errAgentPassphrase):
+		resp.Code = agentCodePassphrase
+	case errors.Is(err, ErrAuth):
+		resp.Code = agentCodeAuth
+	}
+	return resp
+}
+
+// add holds the identities of an identity file, or a passphrase, under the
+// name, replacing any key of that name. Encrypted ssh keys are decrypted
+// before the agent is locked to hold them.
+func (a *Agent) add(req *agentRequest) error {
+	if req.Name == "" {
+		return errors.New("a key needs a name")
+	}
+	if a.isLocked() {
+		return errAgentLocked
+	}
+
+	k := &agentKey{name: req.Name}
+	switch {
+	case len(req.Identity) > 0:
+		k.data = slices.Clone(req.Identity)
+		k.cleanup = append(k.cleanup, internal.ProtectKey(k.data))
+
+		ids, err := ParseIdentityFile(k.data, func() ([]byte, error) {
+			if len(req.Passphrase) == 0 {
+				return nil, errAgentPassphrase
+			}
+			return slices.Clone(req.Passphrase), nil
+		})
+		if err != nil {
+			k.wipe()
+			return err
+		}
+
+		// encrypted ssh keys are decrypted now, nobody can be asked later
+		for _, id := range ids {
+			if s, ok := id.(*SSHIdentity); ok {
+				if _, err := s.privateKey(); err != nil {
+					k.wipe()
+					return err
+				}
+			}
+		}
+		k.ids = ids
+	case len(req.Passphrase) > 0:
+		k.pass = slices.Clone(req.Passphrase)
+		k.cleanup = append(k.cleanup, internal.ProtectKey(k.pass))
+		k.ids = []Identity{&agentPassphrase{agent: a, key: k, maxMemory: a.MaxKDFMemory}}
+	default:
+		return errors.New("nothing to add, give an identity or a passphrase")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock != nil {
+		k.wipe()
+		return errAgentLocked
+	}
+
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = a.TTL
+	}
+	if ttl > 0 {
+		k.expires = time.Now().Add(ttl)
+		k.timer = time.AfterFunc(ttl, func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.drop(k)
+		})
+	}
+
+	if i := a.index(req.Name); i >= 0 {
+		a.drop(a.keys[i])
+	}
+	a.keys = append(a.keys, k)
+	return nil
+}
+
+func (a *Agent) list() ([]AgentKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock != nil {
+		return nil, errAgentLocked
+	}
+
+	keys := []AgentKey{}
+	for _, k := range a.keys {
+		keys = append(keys, AgentKey{Name: k.name, Passphrase: k.pass != nil, Expires: k.expires})
+	}
+	return keys, nil
+}
+
+func (a *Agent) remove(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock != nil {
+		return errAgentLocked
+	}
+
+	i := a.index(name)
+	if i < 0 {
+		return fmt.Errorf("no key named %q", name)
+	}
+	a.drop(a.keys[i])
+	return nil
+}
+
+func (a *Agent) index(name string) int {
+	return slices.IndexFunc(a.keys, func(k *agentKey) bool { return k.name == name })
+}
+
+// drop wipes k and forgets it, if it is still held.
+func (a *Agent) drop(k *agentKey) {
+	i := slices.Index(a.keys, k)
+	if i < 0 {
+		return
+	}
+	a.keys = slices.Delete(a.keys, i, i+1)
+	k.wipe()
+}
+
+func (a *Agent) removeAll() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, k := range a.keys {
+		k.wipe()
+	}
+	a.keys = nil
+	internal.Wipe(a.lock)
+}
+
+func (a *Agent) isLocked() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lock != nil
+}
+
+// lockWith refuses every request but unlock until the same passphrase is
+// given again. The keys stay held and keep expiring.
+func (a *Agent) lockWith(pass []byte) error {
+	if a.isLocked() {
+		return errAgentLocked
+	}
+	if len(pass) == 0 {
+		return errors.New("a lock passphrase is required")
+	}
+
+	salt, err := internal.GenerateSalt16()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+	key := internal.DeriveKey(pass, salt, internal.DefaultKDFParams())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lock != nil {
+		internal.Wipe(key)
+		return errAgentLocked
+	}
+	a.lockSalt, a.lock = salt, key
+	return nil
+}
+
+func (a *Agent) unlockWith(pass []byte) error {
+	a.mu.Lock()
+	salt := a.lockSalt
+	locked := a.lock != nil
+	a.mu.Unlock()
+	if !locked {
+		return errors.New("agent is not locked")
+	}
+
+	key := internal.DeriveKey(pass, salt, internal.DefaultKDFParams())
+	defer internal.Wipe(key)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// another unlock may have come first
+	if a.lock == nil {
+		return errors.New("agent is not locked")
+	}
+	if subtle.ConstantTimeCompare(key, a.lock) != 1 {
+		return fmt.Errorf("%w: wrong lock passphrase", ErrAuth)
+	}
+	internal.Wipe(a.lock)
+	a.lock = nil
+	return nil
+}
+
+// unwrap tries every held key on st.
+func (a *Agent) unwrap(st internal.Stanza) ([]byte, error) {
+	a.mu.Lock()
+	if a.lock != nil {
+		a.mu.Unlock()
+		return nil, errAgentLocked
+	}
+	held := make([][]Identity, 0, len(a.keys))
+	for _, k := range a.keys {
+		held = append(held, k.ids)
+	}
+	a.mu.Unlock()
+
+	for _, ids := range held {
+		fileKey, _, err := unwrapFileKey(ids, []internal.Stanza{st})
+		if errors.Is(err, ErrNoIdentity) {
+			continue
+		}
+		return fileKey, err
+	}
+	return nil, ErrNoIdentity
+}
+
+// derive returns the key of a passphrase file for every held passphrase, in
+// every form the file may have used.
+func (a *Agent) derive(h agentHeader) ([]agentDerived, error) {
+	header := fileHeader{version: h.Version, salt: h.Salt, keyfile: len(h.Keyfile) > 0}
+	if header.version == 1 {
+		header.kdf = internal.DefaultKDFParams()
+	} else {
+		kdf, err := internal.DecodeKDFParams(h.KDF)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+		maxMemory := a.MaxKDFMemory
+		if maxMemory == 0 {
+			maxMemory = internal.DefaultMaxKDFMemory
+		}
+		if err := internal.CheckKDFParams(kdf, maxMemory); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKDFLimit, err)
+		}
+		header.kdf = kdf
+	}
+
+	keyfileSum := sha256.Sum256(h.Keyfile)
+
+	a.mu.Lock()
+	if a.lock != nil {
+		a.mu.Unlock()
+		return nil, errAgentLocked
+	}
+	var held []*agentKey
+	for _, k := range a.keys {
+		if k.pass != nil {
+			held = append(held, k)
+		}
+	}
+	a.mu.Unlock()
+
+	var derived []agentDerived
+	for _, k := range held {
+		kpass := a.passphrase(k)
+		if kpass == nil {
+			continue
+		}
+		wipePass := internal.ProtectKey(kpass)
+
+		var seen [][]byte
+		for _, form := range h.Forms {
+			// forms that produce the same bytes derive the same key
+			pass := internal.NormalizePass(kpass, form)
+			if slices.ContainsFunc(seen, func(s []byte) bool { return subtle.ConstantTimeCompare(s, pass) == 1 }) {
+				internal.Wipe(pass)
+				continue
+			}
+			seen = append(seen, pass)
+
+			id := fmt.Sprintf("file:%d:%x:%x:%d:%x", h.Version, h.Salt, h.KDF, form, keyfileSum)
+			key := a.cached(k, id)
+			if key == nil {
+				key = header.deriveKey(pass, h.Keyfile)
+				a.remember(k, id, key)
+			}
+			derived = append(derived, agentDerived{Form: form, Key: key})
+		}
+		for _, s := range seen {
+			internal.Wipe(s)
+		}
+		wipePass()
+	}
+	return derived, nil
+}
+
+// passphrase returns a copy of the passphrase of k, nil once k is dropped.
+func (a *Agent) passphrase(k *agentKey) []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if k.ids == nil {
+		return nil
+	}
+	return slices.Clone(k.pass)
+}
+
+// cached returns a copy of the key k caches under id, nil when there is
+// none.
+func (a *Agent) cached(k *agentKey, id string) []byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if key, ok := k.derived[id]; ok {
+		return slices.Clone(key)
+	}
+	return nil
+}
+
+// remember caches a copy of key under id, unless k was dropped while it was
+// derived.
+func (a *Agent) remember(k *agentKey, id string, key []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if k.ids == nil {
+		return
+	}
+
+	if k.derived == nil || len(k.derived) >= agentMaxDerived {
+		k.forgetDerived()
+		k.derived = make(map[string][]byte)
+	}
+
+	key = slices.Clone(key)
+	k.wipeDerived = append(k.wipeDerived, internal.ProtectKey(key))
+	k.derived[id] = key
+}
+
+func (k *agentKey) forgetDerived() {
+	for _, f := range k.wipeDerived {
+		f()
+	}
+	k.wipeDerived = nil
+	k.derived = nil
+}
+
+func (k *agentKey) wipe() {
+	if k.timer != nil {
+		k.timer.Stop()
+	}
+	k.forgetDerived()
+	for _, f := range k.cleanup {
+		f()
+	}
+	k.cleanup = nil
+	k.ids = nil
+}
+
+// agentPassphrase unwraps passphrase stanzas like PassphraseIdentity, keeping
+// the key derived for each stanza so opening the file again is quick.
+type agentPassphrase struct {
+	agent     *Agent
+	key       *agentKey
+	maxMemory uint32
+}
+
+func (p *agentPassphrase) unwrap(st internal.Stanza) ([]byte, error) {
+	salt, kdf, wrapped, err := passphraseStanza(st, p.maxMemory)
+	if err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprintf("stanza:%x", st.Body[:16+internal.KDFParamsSize])
+	key := p.agent.cached(p.key, id)
+	if key == nil {
+		// dropped while the request waited
+		pass := p.agent.passphrase(p.key)
+		if pass == nil {
+			return nil, errWrongIdentity
+		}
+		key = passphraseStanzaKey(pass, salt, kdf)
+		internal.Wipe(pass)
+		p.agent.remember(p.key, id, key)
+	}
+	defer internal.Wipe(key)
+
+	return openFileKey(key, salt, passphraseInfo, wrapped)
+}
+
+// AgentClient sends requests to the agent listening on a socket.
+type AgentClient struct {
+	path string
+}
+
+// NewAgentClient returns a client for the agent listening on path.
+func NewAgentClient(path string) *AgentClient {
+	return &AgentClient{path: path}
+}
+
+func (c *AgentClient) call(req agentRequest) (*agentResponse, error) {
+	conn, err := net.DialTimeout("unix", c.path, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no agent at %s: %w", ErrAgent, c.path, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(agentTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAgent, err)
+	}
+
+	var resp agentResponse
+	if err := json.NewDecoder(io.LimitReader(conn, agentMaxMessage)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("%w: no response: %w", ErrAgent, err)
+	}
+
+	switch {
+	case resp.Code == agentCodeWrong:
+		return nil, errWrongIdentity
+	case resp.Code == agentCodePassphrase:
+		return nil, errAgentPassphrase
+	case resp.Code == agentCodeAuth:
+		return nil, fmt.Errorf("%w: %s", ErrAuth, strings.TrimPrefix(resp.Error, ErrAuth.Error()+": "))
+	case resp.Error != "":
+		return nil, fmt.Errorf("%w: %s", ErrAgent, resp.Error)
+	}
+	return &resp, nil
+}
+
+// AddIdentity hands the agent the identities of an identity file, or an ssh
+// private key, to hold under name for ttl, see AgentNoExpiry.
+// passphrase is called when the ssh key is encrypted.
+func (c *AgentClient) AddIdentity(name string, data []byte, passphrase func() ([]byte, error), ttl time.Duration) error {
+	_, err := c.call(agentRequest{Op: "add", Name: name, Identity: data, TTL: ttl})
+	if !errors.Is(err, errAgentPassphrase) {
+		return err
+	}
+	if passphrase == nil {
+		return fmt.Errorf("%w: ssh key is encrypted and no passphrase can be asked for", ErrIdentity)
+	}
+
+	pass, err := passphrase()
+	if err != nil {
+		return err
+	}
+	defer internal.Wipe(pass)
+
+	_, err = c.call(agentRequest{Op: "add", Name: name, Identity: data, Passphrase: pass, TTL: ttl})
+	return err
+}
+
+// AddPassphrase hands the agent a passphrase to hold under name for ttl, see
+// AgentNoExpiry.
+func (c *AgentClient) AddPassphrase(name string, pass []byte, ttl time.Duration) error {
+	_, err := c.call(agentRequest{Op: "add", Name: name, Passphrase: pass, TTL: ttl})
+	return err
+}
+
+// Keys lists the keys the agent holds.
+func (c *AgentClient) Keys() ([]AgentKey, error) {
+	resp, err := c.call(agentRequest{Op: "list"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Keys, nil
+}
+
+// Remove wipes the key held under name.
+func (c *AgentClient) Remove(name string) error {
+	_, err := c.call(agentRequest{Op: "remove", Name: name})
+	return err
+}
+
+// Lock makes the agent refuse every request until Unlock is given pass.
+func (c *AgentClient) Lock(pass []byte) error {
+	_, err := c.call(agentRequest{Op: "lock", Passphrase: pass})
+	return err
+}
+
+// Unlock reverses Lock, failing with ErrAuth for another passphrase.
+func (c *AgentClient) Unlock(pass []byte) error {
+	_, err := c.call(agentRequest{Op: "unlock", Passphrase: pass})
+	return err
+}
+
+// Stop makes the agent wipe its keys and exit.
+func (c *AgentClient) Stop() error {
+	_, err := c.call(agentRequest{Op: "stop"})
+	return err
+}
+
+// Identity returns an identity that has the agent unwrap stanzas. Given in
+// Options.Identities without a passphrase, it also has the agent derive the
+// key of genc files encrypted with a passphrase alone.
+func (c *AgentClient) Identity() Identity {
+	return &agentIdentity{client: c}
+}
+
+type agentIdentity struct {
+	client *AgentClient
+}
+
+func (i *agentIdentity) unwrap(st internal.Stanza) ([]byte, error) {
+	resp, err := i.client.call(agentRequest{Op: "unwrap", Stanza: st})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Key, nil
+}
+
+// agentKeys has the agent of the first agent identity in ids derive the
+// candidate keys of a passphrase file, nil when there is no agent.
+func agentKeys(ids []Identity, keyfile []byte, header fileHeader, forms []PassphraseForm) (*keyCandidates, error) {
+	for _, id := range ids {
+		a, ok := id.(*agentIdentity)
+		if !ok {
+			continue
+		}
+
+		h := agentHeader{Version: header.version, Salt: header.salt, Forms: forms, Keyfile: keyfile}
+		if header.version != 1 {
+			h.KDF = internal.EncodeKDFParams(header.kdf)
+		}
+		resp, err := a.client.call(agentRequest{Op: "derive", Header: h})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Derived) == 0 {
+			return nil, fmt.Errorf("%w: the agent holds no passphrase", ErrNoIdentity)
+		}
+		return &keyCandidates{header: header, derived: resp.Derived}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,288 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// agentClientEnv makes the test binary ask the agent on the socket it names
+// for its keys, when started by TestAgentSocket as another user.
+const agentClientEnv = "GO_ENC_TEST_AGENT_CLIENT"
+
+// serveAgent serves a on a socket in dir until the test ends.
+func serveAgent(t *testing.T, a *Agent, dir string) (*AgentClient, string) {
+	t.Helper()
+
+	path := filepath.Join(dir, "agent", "agent.sock")
+	l, err := ListenAgent(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+	return NewAgentClient(path), path
+}
+
+// agentFiles returns an identity file and plaintext encrypted to it, and to
+// fuzzPass.
+func agentFiles(t *testing.T, plaintext []byte) (identity, toID, toPass []byte) {
+	t.Helper()
+
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := EncryptStream(nil, bytes.NewReader(plaintext), &buf, Options{Recipients: []Recipient{id.Recipient()}}); err != nil {
+		t.Fatal(err)
+	}
+	return []byte(id.String() + "\n"), buf.Bytes(), fuzzStanzaFile(t, plaintext, 1)
+}
+
+// agentDecrypt decrypts data with the agent alone.
+func agentDecrypt(client *AgentClient, data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	err := DecryptStream(nil, bytes.NewReader(data), &out, Options{Identities: []Identity{client.Identity()}})
+	return out.Bytes(), err
+}
+
+func TestAgentKeys(t *testing.T) {
+	client, _ := serveAgent(t, &Agent{}, t.TempDir())
+	plaintext := []byte("KEY=value\n")
+	identity, toID, toPass := agentFiles(t, plaintext)
+
+	if err := client.AddIdentity("id", identity, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AddPassphrase("pass", fuzzPass, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := client.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AgentKey{{Name: "id"}, {Name: "pass", Passphrase: true}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got %+v, want %+v", keys, want)
+	}
+
+	for _, data := range [][]byte{toID, toPass} {
+		got, err := agentDecrypt(client, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("got %q, want %q", got, plaintext)
+		}
+	}
+
+	if err := client.Remove("id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Remove("id"); !errors.Is(err, ErrAgent) {
+		t.Fatalf("got %v, want %v", err, ErrAgent)
+	}
+	if _, err := agentDecrypt(client, toID); !errors.Is(err, ErrNoIdentity) {
+		t.Fatalf("got %v, want %v", err, ErrNoIdentity)
+	}
+	if keys, err := client.Keys(); err != nil || len(keys) != 1 || keys[0].Name != "pass" {
+		t.Fatalf("got %+v, %v, want only pass", keys, err)
+	}
+}
+
+func TestAgentRefusesUnwrap(t *testing.T) {
+	plaintext := []byte("KEY=value\n")
+	identity, toID, _ := agentFiles(t, plaintext)
+
+	t.Run("expired", func(t *testing.T) {
+		client, _ := serveAgent(t, &Agent{TTL: time.Hour}, t.TempDir())
+		if err := client.AddIdentity("id", identity, nil, time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			keys, err := client.Keys()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("key still held: %+v", keys)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if _, err := agentDecrypt(client, toID); !errors.Is(err, ErrNoIdentity) {
+			t.Fatalf("got %v, want %v", err, ErrNoIdentity)
+		}
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		client, _ := serveAgent(t, &Agent{}, t.TempDir())
+		if err := client.AddIdentity("id", identity, nil, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Lock([]byte("lock")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := agentDecrypt(client, toID); !errors.Is(err, ErrAgent) {
+			t.Fatalf("got %v, want %v", err, ErrAgent)
+		}
+		if _, err := client.Keys(); !errors.Is(err, ErrAgent) {
+			t.Fatalf("got %v, want %v", err, ErrAgent)
+		}
+		if err := client.Unlock([]byte("wrong")); !errors.Is(err, ErrAuth) {
+			t.Fatalf("got %v, want %v", err, ErrAuth)
+		}
+
+		if err := client.Unlock([]byte("lock")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := agentDecrypt(client, toID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("got %q, want %q", got, plaintext)
+		}
+	})
+}
+
+func TestAgentSocket(t *testing.T) {
+	if path := os.Getenv(agentClientEnv); path != "" {
+		if _, err := NewAgentClient(path).Keys(); err == nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	_, path := serveAgent(t, &Agent{}, t.TempDir())
+	for name, want := range map[string]os.FileMode{path: 0o600, filepath.Dir(path): 0o700} {
+		info, err := os.Lstat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != want {
+			t.Fatalf("%s is %o, want %o", name, perm, want)
+		}
+	}
+
+	if _, err := ListenAgent(path); !errors.Is(err, ErrAgent) {
+		t.Fatalf("second agent: got %v, want %v", err, ErrAgent)
+	}
+	open := filepath.Join(t.TempDir(), "open")
+	if err := os.Mkdir(open, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenAgent(filepath.Join(open, "agent.sock")); !errors.Is(err, ErrAgent) {
+		t.Fatalf("shared directory: got %v, want %v", err, ErrAgent)
+	}
+
+	t.Run("other user", func(t *testing.T) {
+		// root can ask as another user, only Linux tells the agent who asks
+		if os.Getuid() != 0 || runtime.GOOS != "linux" {
+			t.Skip("needs root on linux")
+		}
+
+		// everything is opened up so only the check of the peer is left
+		dir, err := os.MkdirTemp("", "genc-agent")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := os.Chmod(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		client, path := serveAgent(t, &Agent{}, dir)
+		if err := client.AddPassphrase("pass", fuzzPass, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, 0o666); err != nil {
+			t.Fatal(err)
+		}
+
+		bin := filepath.Join(dir, "genc.test")
+		copyExecutable(t, bin)
+
+		cmd := exec.Command(bin, "-test.run=^TestAgentSocket$")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), agentClientEnv+"="+path)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: 65534, Gid: 65534}}
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("another user was answered: %v %s", err, out)
+		}
+	})
+}
+
+// copyExecutable copies the test binary to path, where another user can run
+// it.
+func copyExecutable(t *testing.T, path string) {
+	t.Helper()
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.Open(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		t.Fatal(err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/ProtonMail/go-crypto/openpgp/s2k"
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// fuzzPass is the passphrase of the seed files.
+var fuzzPass = []byte("correct horse battery staple")
+
+// fuzzKDF is the cheapest accepted key derivation, so every input is quick to try.
+var fuzzKDF = internal.KDFParams{Memory: internal.MinKDFMemory, Iterations: 1, Threads: 1}
+
+// fuzzFile encrypts plaintext with fuzzPass and fuzzKDF.
+func fuzzFile(f *testing.F, plaintext []byte, md *Metadata) []byte {
+	header := internal.HeaderV2{KDF: fuzzKDF, PassForm: internal.PassNFC}
+	hash := internal.DeriveKey(fuzzPass, header.Salt[:], header.KDF)
+
+	var buf bytes.Buffer
+	if err := encryptStream(hash, header, md, bytes.NewReader(plaintext), &buf); err != nil {
+		f.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fuzzStanzaFile encrypts plaintext to n passphrase stanzas, all wrapping
+// the file key with fuzzPass and fuzzKDF.
+func fuzzStanzaFile(tb testing.TB, plaintext []byte, n int) []byte {
+	fileKey := make([]byte, fileKeySize)
+	salt := make([]byte, 16)
+
+	key := passphraseStanzaKey(fuzzPass, salt, fuzzKDF)
+	wrapped, err := sealFileKey(key, salt, passphraseInfo, fileKey)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	body := append(salt, internal.EncodeKDFParams(fuzzKDF)...)
+	st := internal.Stanza{Type: internal.StanzaPassphrase, Body: append(body, wrapped...)}
+
+	header := internal.HeaderV2{KDF: fuzzKDF, PassForm: internal.PassNFC}
+	for range n {
+		header.Stanzas = append(header.Stanzas, st)
+	}
+
+	var buf bytes.Buffer
+	if err := encryptStream(fileKey, header, nil, bytes.NewReader(plaintext), &buf); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fuzzAgeFile encrypts plaintext to fuzzPass as an age file with a cheap
+// scrypt stanza.
+func fuzzAgeFile(f *testing.F, plaintext []byte) []byte {
+	fileKey := make([]byte, ageFileKeySize)
+	salt := make([]byte, 16)
+
+	key, err := ageScryptKey(fuzzPass, salt, 10)
+	if err != nil {
+		f.Fatal(err)
+	}
+	wrapped, err := ageSeal(key, fileKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	st := &ageStanza{typ: "scrypt", args: []string{ageBase64.EncodeToString(salt), "10"}, body: wrapped}
+	header, err := marshalAgeHeader([]*ageStanza{st}, fileKey)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	aw := &ageWriter{fileKey: fileKey, header: header}
+	if err := aw.write(bytes.NewReader(plaintext), &buf, nil); err != nil {
+		f.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// fuzzPGPFile encrypts plaintext to fuzzPass the way gpg --symmetric does.
+func fuzzPGPFile(f *testing.F, plaintext []byte) []byte {
+	var buf bytes.Buffer
+	w, err := openpgp.SymmetricallyEncrypt(&buf, fuzzPass, nil, &packet.Config{S2KConfig: &s2k.Config{S2KMode: s2k.IteratedSaltedS2K, S2KCount: 1024}})
+	if err != nil {
+		f.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		f.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		f.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func FuzzDecryptStream(f *testing.F) {
+	f.Add(fuzzFile(f, nil, nil))
+	f.Add(fuzzFile(f, []byte("KEY=value\n"), &Metadata{Mode: 0o600}))
+	f.Add(fuzzFile(f, bytes.Repeat([]byte{'a'}, internal.ChunkSize+1), nil))
+	f.Add(fuzzAgeFile(f, nil))
+	f.Add(fuzzAgeFile(f, bytes.Repeat([]byte{'a'}, ageChunkSize+1)))
+	f.Add(fuzzStanzaFile(f, []byte("KEY=value\n"), 1))
+	f.Add(fuzzStanzaFile(f, []byte("KEY=value\n"), 500))
+	f.Add(fuzzPGPFile(f, []byte("KEY=value\n")))
+	f.Add(append([]byte(opensslMagic), make([]byte, 8+32)...))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var md Metadata
+		opts := Options{Metadata: &md, MaxKDFMemory: internal.MinKDFMemory}
+
+		err := DecryptStream(fuzzPass, bytes.NewReader(data), io.Discard, opts)
+		if err == nil {
+			return
+		}
+
+		// malformed input must end in one of the documented errors
+		for _, want := range []error{ErrReadHeader, ErrInvalidHeader, ErrKDFLimit, ErrReadChunk, ErrDecryptChunk, ErrAuth, ErrTruncated, ErrNoIdentity, ErrFormat} {
+			if errors.Is(err, want) {
+				return
+			}
+		}
+		t.Fatalf("unexpected error: %v", err)
+	})
+}
@@ -19,17 +19,15 @@ package genc
 
 import (
 	"bufio"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
-	"math"
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/irrisdev/go-enc/internal"
 )
@@ -43,232 +41,311 @@ var (
 	ErrRemoveOrigin  = errors.New("failed to remove original file")
 	ErrBakFile       = errors.New("failed to backup file")
 	ErrSyncEncFile   = errors.New("failed to sync encrypted file")
+	ErrSyncDecFile   = errors.New("failed to sync decrypted file")
 	ErrOpenFile      = errors.New("failed to open file")
 	ErrCreateFile    = errors.New("failed to create file")
 	ErrWriteHeader   = errors.New("failed to write file header")
 	ErrReadHeader    = errors.New("failed to read file header")
+	ErrReadChunk     = errors.New("failed to read chunk")
+	ErrWriteChunk    = errors.New("failed to write chunk")
+	ErrDecryptChunk  = errors.New("failed to decrypt chunk")
+	ErrAuth          = errors.New("authentication failed, wrong passphrase or tampered file")
+	ErrInvalidHeader = errors.New("invalid file header")
+	ErrTruncated     = errors.New("file is truncated")
+	ErrExists        = errors.New("output file already exists")
+	ErrVerify        = errors.New("failed to verify encrypted file, original kept")
+	ErrMetadata      = errors.New("failed to restore file metadata")
+	ErrInterrupted   = errors.New("interrupted")
+	ErrLocked        = errors.New("file is locked")
+	ErrSymlink       = errors.New("refusing to use symlink")
+	ErrSpecialFile   = errors.New("not a regular file")
+	ErrHardLinks     = errors.New("file has other hard links")
+	ErrKDFLimit      = errors.New("key derivation parameters out of bounds")
+	ErrNoSpace       = errors.New("not enough disk space")
+	ErrRecipient     = errors.New("invalid recipient")
+	ErrIdentity      = errors.New("invalid identity")
+	ErrNoIdentity    = errors.New("no identity or passphrase matches the file's recipients")
+	ErrFormat        = errors.New("unsupported by the file format")
+	ErrKeyfile       = errors.New("keyfile error")
+	ErrShare         = errors.New("invalid share")
+	ErrSignature     = errors.New("signature verification failed")
+	ErrSigningKey    = errors.New("invalid signing key")
+	ErrSign          = errors.New("failed to write signature, encrypted file kept")
+	ErrKeyring       = errors.New("keyring error")
+	ErrAgent         = errors.New("agent error")
 )
 
-func Encrypt(pass string, filename string, deleteOrignal ...bool) error {
+// Encrypt encrypts filename into opts.Output, filename + ".genc" by default,
+// or ".age" with FormatAge. The output is created 0600 and the metadata of
+// filename is stored inside genc files.
+// filename must be a regular file, or a symlink to one with opts.FollowSymlinks.
+func Encrypt(pass []byte, filename string, opts ...Options) error {
+	o := getOptions(opts)
+	path := o.encryptOutput(filename)
 
-	// generate salt
-	salt, err := internal.GenerateSalt16()
+	src, info, err := o.resolveInput(filename)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrNewSalt, err)
+		return err
+	}
+	if o.DeleteOrigin {
+		if err := checkRemovable(src, info, o.ShredPasses); err != nil {
+			return err
+		}
 	}
 
-	// generate hash using argon2id
-	hash, _ := internal.GetArgon2ID(pass, salt)
-
-	// create aes block
-	block, err := aes.NewCipher(hash)
+	// keep other go-enc processes away from both files until done
+	unlock, err := LockFiles(o.Context, o.Wait, src, path)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrNewCipher, err)
+		return err
 	}
+	defer unlock()
 
-	// create new gcm
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return fmt.Errorf("%w: %w", ErrNewGcm, err)
+	if err := o.checkOutput(path); err != nil {
+		return err
 	}
 
 	// open source file
-	inFile, err := os.Open(filename)
+	inFile, err := openInput(src, info)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrOpenFile, err)
+		return err
 	}
 	defer inFile.Close()
 
-	// create encrypted destination file - truncates if already exists
-	outFile, err := os.Create(fmt.Sprintf("%s.genc", filename))
+	md, err := internal.CaptureMetadata(inFile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+
+	fw, err := o.newWriter(pass)
+	if err != nil {
+		return err
+	}
+	defer internal.ProtectKey(fw.key())()
+
+	// fail now rather than when the disk fills up halfway through
+	if err := checkSpace(path, fw.size(info, &md)); err != nil {
+		return err
+	}
+
+	// write into a temp file beside the destination, an existing .genc is
+	// only replaced once the new one is complete
+	outFile, err := internal.CreateAtomic(path)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrCreateFile, err)
 	}
-	defer outFile.Close()
 
 	// check if is completed when function returns otherwise delete outFile
 	completed := false
 	defer func() {
 		if !completed {
-			os.Remove(outFile.Name())
+			outFile.Abort()
 			log.Println("encryption failed")
 		}
 	}()
 
-	// create bufio RW buffers limited to RWSize
-	reader := bufio.NewReaderSize(inFile, internal.RWSize)
-	writer := bufio.NewWriterSize(outFile, internal.RWSize)
-	defer writer.Flush()
-
-	// encode and write file header
-	header := internal.EncodeHeader(hash, salt)
-
-	if _, err := writer.Write(header); err != nil {
-		return fmt.Errorf("%w: %w", ErrWriteHeader, err)
+	// hash the plaintext as it is encrypted so a read back can be compared,
+	// and the ciphertext when it is signed
+	sum := sha256.New()
+	var out io.Writer = outFile
+	signed := sha512.New()
+	if o.SignWith != nil {
+		out = io.MultiWriter(outFile, signed)
+	}
+	if err := fw.write(io.TeeReader(o.reader(inFile), sum), out, &md); err != nil {
+		return err
 	}
 
-	buf := make([]byte, internal.ChunkSize) // 1MiB
-
-	for {
-		n, err := reader.Read(buf)
-		if n > 0 {
-
-			// generate random nonce
-			nonce := make([]byte, gcm.NonceSize())
-			_, err = io.ReadFull(rand.Reader, nonce)
-			if err != nil {
-				return fmt.Errorf("%w: %w", ErrNewNonce, err)
-			}
-
-			// encrypt plaintext using random nonce and buf[:n]
-			ciphertext := gcm.Seal(nil, nonce, buf[:n], nil)
-
-			// encode the chunk header
-			if len(ciphertext) > math.MaxUint32 {
-				return fmt.Errorf("%w: %w", ErrChunkTooLarge, err)
-			}
-
-			header := internal.EncodeChunkHeader(uint32(len(ciphertext)), nonce)
-
-			// write header first then ciphertext
-			writer.Write(header)
-			writer.Write(ciphertext)
-
-		}
-
-		if err == io.EOF {
-			break
-		}
-
-		if err != nil {
+	// the signature is written before the file is committed, so failing to
+	// sign leaves neither behind
+	var sigFile *internal.AtomicFile
+	if o.SignWith != nil {
+		if sigFile, err = createSignature(path, o.SignWith, signed); err != nil {
 			return err
 		}
+		defer sigFile.Abort()
 	}
 
-	// fsync guarantee file write
-	if err := outFile.Sync(); err != nil {
+	// backup existing output, fsync file and directory then rename into place
+	if err := o.commit(outFile); err != nil {
+		if errors.Is(err, ErrExists) || errors.Is(err, ErrBakFile) || errors.Is(err, ErrInterrupted) {
+			return err
+		}
 		return fmt.Errorf("%w: %w", ErrSyncEncFile, err)
 	}
 
 	completed = true
 
-	if len(deleteOrignal) > 0 && deleteOrignal[0] {
-		if err := os.Remove(filename); err != nil {
-			return ErrRemoveOrigin
+	if sigFile != nil {
+		if err := sigFile.Commit(); err != nil {
+			return fmt.Errorf("%w: %w", ErrSign, err)
+		}
+	}
+
+	if o.DeleteOrigin {
+		// the encrypted file is complete, but keep the original when asked to stop
+		if err := o.interrupted(); err != nil {
+			return fmt.Errorf("%w: %w", ErrRemoveOrigin, err)
+		}
+		if err := removeOrigin(src, path, fw, sum.Sum(nil), o.ShredPasses); err != nil {
+			return err
+		}
+
+		// the link would dangle now
+		if src != filename {
+			if err := os.Remove(filename); err != nil {
+				return fmt.Errorf("%w: %w", ErrRemoveOrigin, err)
+			}
+			log.Printf("removed symlink %s along with its target %s\n", filename, src)
 		}
 	}
 
 	return nil
 }
 
-func Decrypt(pass string, filename string, outpath ...string) error {
-	completed := false
-
-	// open file
-	file, err := os.Open(filename)
+// createSignature signs the file about to be committed at path, given the
+// sha512 of its content, into a temporary file that replaces any signature
+// file it had once committed.
+func createSignature(path string, key *SigningKey, h hash.Hash) (*internal.AtomicFile, error) {
+	sig, err := key.sign(h)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrOpenFile, err)
+		return nil, fmt.Errorf("failed to sign: %w", err)
 	}
-	defer file.Close()
 
-	// check if outpath has been specified
-	var path string
-	if len(outpath) > 0 {
-		path = outpath[0]
-	} else {
-		path = strings.TrimSuffix(filename, ".genc")
+	f, err := internal.CreateAtomic(path + SignatureExt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
+	if _, err := f.Write(sig); err != nil {
+		f.Abort()
+		return nil, fmt.Errorf("%w: %w", ErrCreateFile, err)
 	}
+	return f, nil
+}
 
-	// check if file already exists, create backup if it does
-	if internal.FileExists(path) {
-		backup := fmt.Sprintf("%s.bak", path)
+// removeOrigin deletes the plaintext filename, but only once the encrypted file
+// at path has been read back in full and decrypts to the same content.
+func removeOrigin(filename string, path string, fw fileWriter, sum []byte, shredPasses int) error {
+	if err := verify(path, fw, sum); err != nil {
+		return err
+	}
 
-		log.Printf("output file %s already exists, creating backup at: %s\n", filepath.Base(path), backup)
+	if shredPasses > 0 {
+		if fs, ok := internal.CopyOnWriteFS(filename); ok {
+			log.Printf("warning: %s is on %s, a copy-on-write filesystem, shredding may not destroy the original blocks\n", filename, fs)
+		}
 
-		if err := internal.CopyFile(path, backup); err != nil {
-			return fmt.Errorf("%w: %w", ErrBakFile, err)
+		if err := internal.ShredFile(filename, shredPasses); err != nil {
+			return fmt.Errorf("%w: %w", ErrRemoveOrigin, err)
 		}
+		return nil
+	}
+
+	if err := os.Remove(filename); err != nil {
+		return fmt.Errorf("%w: %w", ErrRemoveOrigin, err)
 	}
 
-	outFile, err := os.Create(path)
+	return nil
+}
+
+// verify decrypts the file at path with the key of fw and checks the
+// plaintext matches sum.
+func verify(path string, fw fileWriter, sum []byte) error {
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrCreateFile, err)
+		return fmt.Errorf("%w: %w", ErrVerify, err)
 	}
+	defer file.Close()
 
-	defer func() {
-		if !completed {
-			os.Remove(outFile.Name())
-			log.Println("decryption failed")
-		}
-	}()
+	h := sha256.New()
+	if err := fw.verify(bufio.NewReaderSize(file, internal.RWSize), h); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerify, err)
+	}
+
+	if !bytes.Equal(h.Sum(nil), sum) {
+		return fmt.Errorf("%w: plaintext does not match", ErrVerify)
+	}
 
-	// create buffered io reader
-	reader := bufio.NewReaderSize(file, internal.RWSize)
-	writer := bufio.NewWriterSize(outFile, internal.RWSize)
-	defer writer.Flush()
+	return nil
+}
 
-	// create buffer of exact header size
-	headerBuf := make([]byte, internal.HeaderSize)
+// Decrypt decrypts filename into opts.Output, filename without its extension
+// by default.
+// An existing output is only backed up or replaced once decryption succeeded.
+// The output is created 0600 unless opts.Preserve restores the stored metadata.
+func Decrypt(pass []byte, filename string, opts ...Options) error {
+	o := getOptions(opts)
+	path := o.decryptOutput(filename)
 
-	// read full 52 bytes of header, err if cannot
-	_, err = io.ReadFull(reader, headerBuf)
+	src, info, err := o.resolveInput(filename)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrReadHeader, err)
+		return err
 	}
 
-	// decode and validate header matches magic
-	header, err := internal.DecodeHeader(headerBuf)
+	// keep other go-enc processes away from both files until done
+	unlock, err := LockFiles(o.Context, o.Wait, src, path)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	hash, _ := internal.GetArgon2ID(pass, header.Salt[:])
+	if err := o.checkOutput(path); err != nil {
+		return err
+	}
 
-	// create aes block
-	block, err := aes.NewCipher(hash)
-	if err != nil {
-		return fmt.Errorf("%w: %w", ErrNewCipher, err)
+	// the plaintext is never larger than the encrypted file, zero runs of
+	// sparse files become holes
+	if err := checkSpace(path, info.Size()); err != nil {
+		return err
 	}
 
-	// create new gcm
-	gcm, err := cipher.NewGCM(block)
+	completed := false
+
+	// open file
+	file, err := openInput(src, info)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrNewGcm, err)
+		return err
 	}
+	defer file.Close()
 
-	for {
-		// read 15 byte chunk header
-		chunkHeader, chunkErr := internal.ReadChunkHeader(reader)
-		if chunkErr == io.EOF || chunkErr == io.ErrUnexpectedEOF { // unsure if ErrUnexpectedEOF should return error
-			break
-		}
-
-		if chunkErr != nil {
+	// DecryptStream checks the signature over the same read it decrypts
+	if len(o.VerifyWith) > 0 {
+		if o.Signature, err = ReadSignature(filename); err != nil {
 			return err
 		}
+	}
 
-		// create dynamic buffer to chunk size
-		buf := make([]byte, chunkHeader.Length)
-
-		// read full into chunk buffer
-		n, err := io.ReadFull(reader, buf)
+	outFile, err := internal.CreateAtomic(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
 
-		if n > 0 {
-			// attempt to decrypt chunk using nonce in header
-			plaintext, gcmErr := gcm.Open(nil, chunkHeader.Nonce[:], buf, nil)
-			if gcmErr != nil {
-				return fmt.Errorf("%w: %w", gcmErr, gcmErr)
-			}
-			writer.Write(plaintext)
+	defer func() {
+		if !completed {
+			outFile.Abort()
+			log.Println("decryption failed")
 		}
+	}()
+
+	var md Metadata
+	so := o
+	so.Metadata = &md
+	if err := DecryptStream(pass, file, outFile, so); err != nil {
+		return err
+	}
 
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			break
+	// files from before metadata was stored have a zero mode
+	if o.Preserve && md.Mode != 0 {
+		if err := internal.RestoreMetadata(outFile.File, md); err != nil {
+			return fmt.Errorf("%w: %w", ErrMetadata, err)
 		}
+	}
 
-		if err != nil {
+	// backup existing output, fsync file and directory then rename into place
+	if err := o.commit(outFile); err != nil {
+		if errors.Is(err, ErrExists) || errors.Is(err, ErrBakFile) || errors.Is(err, ErrInterrupted) {
 			return err
 		}
+		return fmt.Errorf("%w: %w", ErrSyncDecFile, err)
 	}
 
 	completed = true
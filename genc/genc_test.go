@@ -0,0 +1,193 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+func TestEncryptDeleteOrigin(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, passes := range []int{0, 1, 3} {
+		logs.Reset()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "secrets")
+		if err := os.WriteFile(path, []byte("KEY=value\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		opts := Options{Recipients: []Recipient{id.Recipient()}, DeleteOrigin: true, ShredPasses: passes}
+		if err := Encrypt(nil, path, opts); err != nil {
+			t.Fatalf("%d passes: %v", passes, err)
+		}
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%d passes: original still exists: %v", passes, err)
+		}
+
+		data, err := os.ReadFile(path + ".genc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := DecryptStream(nil, bytes.NewReader(data), &out, Options{Identities: []Identity{id}}); err != nil || out.String() != "KEY=value\n" {
+			t.Errorf("%d passes: decrypted %q, %v", passes, out.String(), err)
+		}
+
+		// only shredding warns, and only where the filesystem keeps old blocks
+		_, cow := internal.CopyOnWriteFS(dir)
+		if warned := strings.Contains(logs.String(), "copy-on-write"); warned != (cow && passes > 0) {
+			t.Errorf("%d passes: copy-on-write warning %v on a copy-on-write filesystem %v", passes, warned, cow)
+		}
+	}
+}
+
+// interruptAfter is a context that is done from its n+1th check on, to
+// interrupt at each point that looks for it in turn.
+type interruptAfter struct {
+	context.Context
+	n atomic.Int32
+}
+
+func (c *interruptAfter) Err() error {
+	if c.n.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestInterruptedLeavesNothing(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var enc bytes.Buffer
+	if err := EncryptStream(nil, strings.NewReader("new"), &enc, Options{Recipients: []Recipient{id.Recipient()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		run  func(dir string, ctx context.Context) error
+		// files before, left as they were when interrupted
+		files map[string]string
+		// an output that may be left once finished, it must then decrypt
+		finished string
+	}{
+		{
+			name: "encrypt",
+			run: func(dir string, ctx context.Context) error {
+				return Encrypt(nil, filepath.Join(dir, "secrets"), Options{Recipients: []Recipient{id.Recipient()}, Context: ctx})
+			},
+			files: map[string]string{"secrets": "KEY=value\n"},
+		},
+		{
+			name: "encrypt over an existing file",
+			run: func(dir string, ctx context.Context) error {
+				return Encrypt(nil, filepath.Join(dir, "secrets"), Options{Recipients: []Recipient{id.Recipient()}, Overwrite: Force, Context: ctx})
+			},
+			files: map[string]string{"secrets": "KEY=value\n", "secrets.genc": "old"},
+		},
+		{
+			name: "encrypt deleting the original",
+			run: func(dir string, ctx context.Context) error {
+				return Encrypt(nil, filepath.Join(dir, "secrets"), Options{Recipients: []Recipient{id.Recipient()}, DeleteOrigin: true, Context: ctx})
+			},
+			files:    map[string]string{"secrets": "KEY=value\n"},
+			finished: "secrets.genc",
+		},
+		{
+			name: "decrypt with a backup",
+			run: func(dir string, ctx context.Context) error {
+				return Decrypt(nil, filepath.Join(dir, "secrets.genc"), Options{Identities: []Identity{id}, Backup: BackupNumbered, Context: ctx})
+			},
+			files: map[string]string{"secrets.genc": enc.String(), "secrets": "old"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// interrupt at every check in turn until one run gets through
+			for n := int32(0); ; n++ {
+				if n > 100 {
+					t.Fatal("never finished")
+				}
+
+				dir := t.TempDir()
+				for name, data := range tc.files {
+					if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				ctx := &interruptAfter{Context: context.Background()}
+				ctx.n.Store(n)
+				err := tc.run(dir, ctx)
+				if err == nil {
+					if n == 0 {
+						t.Fatal("never checked for an interruption")
+					}
+					break
+				}
+				if !errors.Is(err, ErrInterrupted) {
+					t.Fatalf("check %d: got %v, want %v", n, err, ErrInterrupted)
+				}
+
+				// past the commit the original is kept beside the complete file
+				want := len(tc.files)
+				if data, err := os.ReadFile(filepath.Join(dir, tc.finished)); tc.finished != "" && err == nil {
+					var out bytes.Buffer
+					if err := DecryptStream(nil, bytes.NewReader(data), &out, Options{Identities: []Identity{id}}); err != nil {
+						t.Errorf("check %d: left an unfinished %s: %v", n, tc.finished, err)
+					}
+					want++
+				}
+
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) != want {
+					t.Errorf("check %d: left %v behind", n, entries)
+				}
+				for name, want := range tc.files {
+					if got, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(got) != want {
+						t.Errorf("check %d: %s changed: %v", n, name, err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// Import converts filename, an OpenPGP message or openssl enc file encrypted
+// with pass, into a genc file at opts.Output, by default filename without its
+// extension plus ".genc". The new file is encrypted with pass, or to
+// opts.Recipients when set. Decryption and encryption run in one streaming
+// pass and the new file is only kept once the old one decrypted in full and
+// passed its integrity check.
+func Import(pass []byte, filename string, opts ...Options) error {
+	o := getOptions(opts)
+	path := o.Output
+	if path == "" {
+		path = PlainName(filename) + o.Format.ext()
+	}
+
+	src, info, err := o.resolveInput(filename)
+	if err != nil {
+		return err
+	}
+
+	// keep other go-enc processes away from both files until done
+	unlock, err := LockFiles(o.Context, o.Wait, src, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := o.checkOutput(path); err != nil {
+		return err
+	}
+
+	inFile, err := openInput(src, info)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+
+	md, err := internal.CaptureMetadata(inFile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+
+	reader := bufio.NewReaderSize(o.reader(inFile), internal.RWSize)
+	if !isOpenPGP(reader) && !isOpenSSL(reader) {
+		return fmt.Errorf("%w: %s is not an OpenPGP message or openssl enc file", ErrFormat, filename)
+	}
+
+	newPass := pass
+	if len(o.Recipients) > 0 {
+		newPass = nil
+	}
+	fw, err := o.newWriter(newPass)
+	if err != nil {
+		return err
+	}
+	defer internal.ProtectKey(fw.key())()
+
+	// the size of the plaintext is unknown until it is decrypted, compressed
+	// OpenPGP messages may be far larger, so there is no space check
+	outFile, err := internal.CreateAtomic(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
+
+	completed := false
+	defer func() {
+		if !completed {
+			outFile.Abort()
+			log.Println("import failed")
+		}
+	}()
+
+	// the plaintext only ever exists in the pipe
+	pr, pw := io.Pipe()
+	decrypted := make(chan error, 1)
+	go func() {
+		err := o.decryptLegacy(pass, reader, aborted{pw})
+		pw.CloseWithError(err)
+		decrypted <- err
+	}()
+
+	err = fw.write(pr, outFile, &md)
+	pr.CloseWithError(err)
+
+	// a failed decryption explains a failed encryption better
+	if decErr := <-decrypted; decErr != nil && !errors.Is(decErr, io.ErrClosedPipe) {
+		return decErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := o.commit(outFile); err != nil {
+		if errors.Is(err, ErrExists) || errors.Is(err, ErrBakFile) || errors.Is(err, ErrInterrupted) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrSyncEncFile, err)
+	}
+
+	completed = true
+
+	return nil
+}
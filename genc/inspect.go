@@ -0,0 +1,153 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// KDFInfo describes argon2id parameters.
+type KDFInfo struct {
+	MemoryKiB  uint32 `json:"memory_kib"`
+	Iterations uint32 `json:"iterations"`
+	Threads    uint8  `json:"threads"`
+}
+
+// StanzaInfo describes a key stanza by its type only, nothing in it
+// identifies the recipient.
+type StanzaInfo struct {
+	Type string   `json:"type"`
+	KDF  *KDFInfo `json:"kdf,omitempty"`
+	// WorkFactor is the log2 scrypt cost of age passphrase stanzas
+	WorkFactor int `json:"work_factor,omitempty"`
+	// Threshold of Shares recover the key of shares stanzas
+	Threshold int `json:"threshold,omitempty"`
+	Shares    int `json:"shares,omitempty"`
+}
+
+// FileInfo describes how a file is encrypted. OpenPGP and openssl files only
+// get their Format, with a zero Version.
+type FileInfo struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// KDF is set for files encrypted with only a passphrase
+	KDF            *KDFInfo     `json:"kdf,omitempty"`
+	Stanzas        []StanzaInfo `json:"stanzas,omitempty"`
+	PassphraseForm string       `json:"passphrase_form,omitempty"`
+	Metadata       bool         `json:"metadata"`
+	Sparse         bool         `json:"sparse"`
+	Keyfile        bool         `json:"keyfile"`
+}
+
+// Inspect reads the header of a file from r without decrypting anything.
+func Inspect(r io.Reader) (FileInfo, error) {
+	reader := bufio.NewReader(r)
+	switch {
+	case isAge(reader):
+		return inspectAge(reader)
+	case isOpenPGP(reader):
+		return FileInfo{Format: "openpgp"}, nil
+	case isOpenSSL(reader):
+		return FileInfo{Format: "openssl"}, nil
+	}
+
+	info := FileInfo{Format: "genc"}
+
+	header, err := readHeader(reader, math.MaxUint32)
+	if err != nil {
+		return info, err
+	}
+
+	info.Version = header.version
+	info.Metadata = header.metadata
+	info.Sparse = header.sparse
+	info.Keyfile = header.keyfile
+
+	if len(header.stanzas) == 0 {
+		info.KDF = kdfInfo(header.kdf)
+		info.PassphraseForm = header.passForm.String()
+	}
+
+	for _, st := range header.stanzas {
+		info.Stanzas = append(info.Stanzas, stanzaInfo(st))
+	}
+
+	return info, nil
+}
+
+func kdfInfo(p internal.KDFParams) *KDFInfo {
+	return &KDFInfo{MemoryKiB: p.Memory, Iterations: p.Iterations, Threads: p.Threads}
+}
+
+func stanzaInfo(st internal.Stanza) StanzaInfo {
+	switch st.Type {
+	case internal.StanzaX25519:
+		return StanzaInfo{Type: "x25519"}
+	case internal.StanzaPassphrase:
+		info := StanzaInfo{Type: "passphrase"}
+		if len(st.Body) > 16 {
+			if kdf, err := internal.DecodeKDFParams(st.Body[16:min(len(st.Body), 16+internal.KDFParamsSize)]); err == nil {
+				info.KDF = kdfInfo(kdf)
+			}
+		}
+		return info
+	case internal.StanzaSSHEd25519:
+		return StanzaInfo{Type: "ssh-ed25519"}
+	case internal.StanzaSSHRSA:
+		return StanzaInfo{Type: "ssh-rsa"}
+	case internal.StanzaShares:
+		info := StanzaInfo{Type: "shares"}
+		if len(st.Body) > splitIDSize+1 {
+			info.Threshold, info.Shares = int(st.Body[splitIDSize]), int(st.Body[splitIDSize+1])
+		}
+		return info
+	}
+	return StanzaInfo{Type: fmt.Sprintf("unknown-0x%02x", st.Type)}
+}
+
+func inspectAge(reader *bufio.Reader) (FileInfo, error) {
+	info := FileInfo{Format: "age", Version: 1}
+
+	header, err := parseAgeHeader(reader)
+	if err != nil {
+		return info, err
+	}
+
+	for _, st := range header.stanzas {
+		switch st.typ {
+		case "X25519":
+			info.Stanzas = append(info.Stanzas, StanzaInfo{Type: "x25519"})
+		case "scrypt":
+			si := StanzaInfo{Type: "scrypt"}
+			if len(st.args) == 2 {
+				si.WorkFactor, _ = strconv.Atoi(st.args[1])
+			}
+			info.Stanzas = append(info.Stanzas, si)
+		default:
+			info.Stanzas = append(info.Stanzas, StanzaInfo{Type: st.typ})
+		}
+	}
+
+	return info, nil
+}
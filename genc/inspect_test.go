@@ -0,0 +1,114 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+func TestInspect(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipients := []Recipient{id.Recipient(), other.Recipient()}
+
+	v1 := internal.EncodeHeader(nil, make([]byte, 16))
+	v2 := internal.EncodeHeaderV2(internal.HeaderV2{KDF: fuzzKDF, PassForm: internal.PassNFC, Metadata: true, Keyfile: true})
+	stanzas := fuzzStanzaFile(t, []byte("KEY=value\n"), 1)
+
+	var sparse bytes.Buffer
+	if err := EncryptStream(nil, bytes.NewReader(nil), &sparse, Options{Recipients: recipients, Sparse: true}); err != nil {
+		t.Fatal(err)
+	}
+	var age bytes.Buffer
+	if err := EncryptStream(nil, bytes.NewReader(nil), &age, Options{Recipients: recipients, Format: FormatAge}); err != nil {
+		t.Fatal(err)
+	}
+	pgp, err := os.ReadFile("testdata/openpgp/mdc.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fuzzKDFInfo := kdfInfo(fuzzKDF)
+
+	tests := []struct {
+		name string
+		data []byte
+		want FileInfo
+		err  error
+	}{
+		{
+			name: "v1",
+			data: v1,
+			want: FileInfo{Format: "genc", Version: 1, KDF: kdfInfo(internal.DefaultKDFParams()), PassphraseForm: "raw"},
+		},
+		{
+			name: "v2 passphrase",
+			data: v2,
+			want: FileInfo{Format: "genc", Version: 2, KDF: fuzzKDFInfo, PassphraseForm: "NFC", Metadata: true, Keyfile: true},
+		},
+		{
+			name: "v2 passphrase stanza",
+			data: stanzas,
+			want: FileInfo{Format: "genc", Version: 2, Stanzas: []StanzaInfo{{Type: "passphrase", KDF: fuzzKDFInfo}}},
+		},
+		{
+			name: "v2 recipients sparse",
+			data: sparse.Bytes(),
+			want: FileInfo{Format: "genc", Version: 2, Sparse: true, Stanzas: []StanzaInfo{{Type: "x25519"}, {Type: "x25519"}}},
+		},
+		{
+			name: "age",
+			data: age.Bytes(),
+			want: FileInfo{Format: "age", Version: 1, Stanzas: []StanzaInfo{{Type: "x25519"}, {Type: "x25519"}}},
+		},
+		{
+			name: "openpgp",
+			data: pgp,
+			want: FileInfo{Format: "openpgp"},
+		},
+		{name: "empty", data: nil, want: FileInfo{Format: "genc"}, err: ErrTruncated},
+		{name: "v1 truncated", data: v1[:10], want: FileInfo{Format: "genc"}, err: ErrTruncated},
+		{name: "v2 truncated prefix", data: v2[:5], want: FileInfo{Format: "genc"}, err: ErrTruncated},
+		{name: "v2 truncated body", data: v2[:len(v2)-1], want: FileInfo{Format: "genc"}, err: ErrTruncated},
+		{name: "age truncated", data: age.Bytes()[:40], want: FileInfo{Format: "age", Version: 1}, err: ErrTruncated},
+		{name: "not encrypted", data: []byte("KEY=value\n"), want: FileInfo{Format: "genc"}, err: ErrInvalidHeader},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Inspect(bytes.NewReader(tc.data))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+)
+
+// KeyfileSize is the length of keyfiles made by GenerateKeyfile. Any other
+// non-empty file works as a keyfile too.
+const KeyfileSize = 32
+
+// GenerateKeyfile returns KeyfileSize random bytes to be saved as a keyfile.
+func GenerateKeyfile() ([]byte, error) {
+	b := make([]byte, KeyfileSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ReadKeyfile hashes the whole of r into the digest Options.Keyfile takes.
+// Every byte counts, a keyfile must not be edited once files depend on it.
+func ReadKeyfile(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: keyfile is empty", ErrKeyfile)
+	}
+	return h.Sum(nil), nil
+}
+
+// checkKeyfile fails with ErrKeyfile unless a keyfile is given exactly when
+// the file needs one, rather than letting decryption fail authentication.
+func checkKeyfile(needed bool, keyfile []byte) error {
+	switch {
+	case needed && len(keyfile) == 0:
+		return fmt.Errorf("%w: file needs a keyfile", ErrKeyfile)
+	case !needed && len(keyfile) > 0:
+		return fmt.Errorf("%w: file does not use a keyfile", ErrKeyfile)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// keyfileFile encrypts plaintext with fuzzPass and fuzzKDF, mixing in
+// keyfile when set.
+func keyfileFile(t *testing.T, plaintext, keyfile []byte) []byte {
+	t.Helper()
+	header := internal.HeaderV2{KDF: fuzzKDF, PassForm: internal.PassNFC, Keyfile: len(keyfile) > 0}
+	hash := internal.DeriveKey(fuzzPass, header.Salt[:], header.KDF)
+	if header.Keyfile {
+		hash = internal.MixKeyfile(hash, keyfile, header.Salt[:])
+	}
+
+	var buf bytes.Buffer
+	if err := encryptStream(hash, header, nil, bytes.NewReader(plaintext), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestKeyfileMismatch(t *testing.T) {
+	plaintext := []byte("KEY=value\n")
+	keyfile, err := ReadKeyfile(strings.NewReader("something you have"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong, err := ReadKeyfile(strings.NewReader("something else"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recipients, age bytes.Buffer
+	if err := EncryptStream(nil, bytes.NewReader(plaintext), &recipients, Options{Recipients: []Recipient{id.Recipient()}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncryptStream(nil, bytes.NewReader(plaintext), &age, Options{Recipients: []Recipient{id.Recipient()}, Format: FormatAge}); err != nil {
+		t.Fatal(err)
+	}
+
+	withKeyfile := keyfileFile(t, plaintext, keyfile)
+	without := keyfileFile(t, plaintext, nil)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		keyfile []byte
+		err     error
+	}{
+		{name: "keyfile given", data: withKeyfile, keyfile: keyfile},
+		{name: "keyfile missing", data: withKeyfile, err: ErrKeyfile},
+		{name: "wrong keyfile", data: withKeyfile, keyfile: wrong, err: ErrAuth},
+		{name: "no keyfile needed", data: without},
+		{name: "keyfile not needed", data: without, keyfile: keyfile, err: ErrKeyfile},
+		{name: "keyfile with recipients", data: recipients.Bytes(), keyfile: keyfile, err: ErrKeyfile},
+		{name: "keyfile with age", data: age.Bytes(), keyfile: keyfile, err: ErrKeyfile},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := Options{Keyfile: tc.keyfile, Identities: []Identity{id}}
+			err := DecryptStream(fuzzPass, bytes.NewReader(tc.data), &out, opts)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if err == nil && !bytes.Equal(out.Bytes(), plaintext) {
+				t.Errorf("decrypted %q", out.Bytes())
+			}
+		})
+	}
+}
+
+func TestKeyfileRefused(t *testing.T) {
+	if _, err := ReadKeyfile(strings.NewReader("")); !errors.Is(err, ErrKeyfile) {
+		t.Errorf("empty keyfile: got %v, want %v", err, ErrKeyfile)
+	}
+
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyfile, err := GenerateKeyfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Recipients: []Recipient{id.Recipient()}, Keyfile: keyfile}
+	if err := EncryptStream(nil, strings.NewReader("KEY=value\n"), io.Discard, opts); !errors.Is(err, ErrKeyfile) {
+		t.Errorf("keyfile with recipients: got %v, want %v", err, ErrKeyfile)
+	}
+}
@@ -0,0 +1,325 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// keyringVersion is the version of the JSON document inside the keyring.
+const keyringVersion = 1
+
+var keyNameRE = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// KeyringEntry is a named recipient, and its identity when the private key is
+// held as well.
+type KeyringEntry struct {
+	Name      string    `json:"name"`
+	Recipient string    `json:"recipient"`
+	Identity  string    `json:"identity,omitempty"`
+	Added     time.Time `json:"added"`
+}
+
+// Keyring holds named recipients and identities, and groups of names. It is
+// stored as a genc file encrypted with a master passphrase.
+type Keyring struct {
+	Version int                 `json:"version"`
+	Entries []KeyringEntry      `json:"keys"`
+	Groups  map[string][]string `json:"groups,omitempty"`
+}
+
+// KeyringPath returns $XDG_CONFIG_HOME/go-enc/keyring.genc, or the platform's
+// equivalent.
+func KeyringPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrKeyring, err)
+	}
+	return filepath.Join(dir, "go-enc", "keyring.genc"), nil
+}
+
+// OpenKeyring decrypts the keyring at path with pass. A keyring that does not
+// exist yet is returned empty. Changes are saved with UpdateKeyring, which
+// holds the keyring's lock from reading it to replacing it.
+func OpenKeyring(pass []byte, path string) (*Keyring, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &Keyring{Version: keyringVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	defer func() { internal.Wipe(buf.Bytes()) }()
+
+	if err := DecryptStream(pass, f, &buf); err != nil {
+		return nil, err
+	}
+
+	var k Keyring
+	if err := json.Unmarshal(buf.Bytes(), &k); err != nil {
+		return nil, fmt.Errorf("%w: %s is not a keyring: %w", ErrKeyring, path, err)
+	}
+	if k.Version != keyringVersion {
+		return nil, fmt.Errorf("%w: unsupported keyring version %d", ErrKeyring, k.Version)
+	}
+	return &k, nil
+}
+
+// Save encrypts the keyring with pass and replaces the file at path, creating
+// its directory 0700 when needed. It takes no lock, see UpdateKeyring.
+func (k *Keyring) Save(pass []byte, path string) error {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	defer internal.Wipe(data)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
+
+	f, err := internal.CreateAtomic(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
+	defer f.Abort()
+
+	if err := EncryptStream(pass, bytes.NewReader(data), f); err != nil {
+		return err
+	}
+	if err := f.Commit(); err != nil {
+		return fmt.Errorf("%w: %w", ErrSyncEncFile, err)
+	}
+	return nil
+}
+
+// UpdateKeyring opens the keyring at path with pass, applies change and saves
+// it, holding the lock on path throughout so no concurrent update is lost. A
+// keyring that does not exist yet is created.
+func UpdateKeyring(ctx context.Context, pass []byte, path string, change func(k *Keyring) error) error {
+	// the lock lives beside the keyring
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
+
+	unlock, err := LockFiles(ctx, true, path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	k, err := OpenKeyring(pass, path)
+	if err != nil {
+		return fmt.Errorf("keyring %s: %w", path, err)
+	}
+	if err := change(k); err != nil {
+		return err
+	}
+	return k.Save(pass, path)
+}
+
+// AddRecipient adds recipient, in any form ParseRecipient takes, as name.
+func (k *Keyring) AddRecipient(name, recipient string) error {
+	if err := k.checkNewName(name); err != nil {
+		return err
+	}
+	if _, err := ParseRecipient(recipient); err != nil {
+		return err
+	}
+
+	k.Entries = append(k.Entries, KeyringEntry{Name: name, Recipient: recipient, Added: time.Now().UTC()})
+	return nil
+}
+
+// AddIdentity adds the single identity in data, an identity file or an ssh
+// private key, as name along with its recipient.
+func (k *Keyring) AddIdentity(name string, data []byte) error {
+	if err := k.checkNewName(name); err != nil {
+		return err
+	}
+
+	// the public key of an encrypted ssh key is readable without its passphrase
+	ids, err := ParseIdentityFile(data, nil)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return fmt.Errorf("%w: found %d identities, add them one at a time", ErrKeyring, len(ids))
+	}
+
+	var recipient string
+	switch id := ids[0].(type) {
+	case *X25519Identity:
+		recipient = id.Recipient().String()
+	case *SSHIdentity:
+		recipient = id.Recipient().AuthorizedKey()
+	default:
+		return fmt.Errorf("%w: unsupported identity", ErrKeyring)
+	}
+
+	k.Entries = append(k.Entries, KeyringEntry{Name: name, Recipient: recipient, Identity: string(data), Added: time.Now().UTC()})
+	return nil
+}
+
+// AddToGroup adds members, which must be names in the keyring, to the group
+// name, creating it when needed.
+func (k *Keyring) AddToGroup(name string, members []string) error {
+	if !keyNameRE.MatchString(name) {
+		return fmt.Errorf("%w: invalid group name %q", ErrKeyring, name)
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("%w: group @%s needs members", ErrKeyring, name)
+	}
+
+	if k.Groups == nil {
+		k.Groups = make(map[string][]string)
+	}
+	group := k.Groups[name]
+	for _, m := range members {
+		if k.Entry(m) == nil {
+			return fmt.Errorf("%w: no key named %q", ErrKeyring, m)
+		}
+		if !slices.Contains(group, m) {
+			group = append(group, m)
+		}
+	}
+	k.Groups[name] = group
+	return nil
+}
+
+// Remove removes the key name, also from every group, or the group @name.
+func (k *Keyring) Remove(name string) error {
+	if group, ok := strings.CutPrefix(name, "@"); ok {
+		if _, exists := k.Groups[group]; !exists {
+			return fmt.Errorf("%w: no group named %q", ErrKeyring, name)
+		}
+		delete(k.Groups, group)
+		return nil
+	}
+
+	i := slices.IndexFunc(k.Entries, func(e KeyringEntry) bool { return e.Name == name })
+	if i < 0 {
+		return fmt.Errorf("%w: no key named %q", ErrKeyring, name)
+	}
+	k.Entries = slices.Delete(k.Entries, i, i+1)
+
+	for g, members := range k.Groups {
+		k.Groups[g] = slices.DeleteFunc(members, func(m string) bool { return m == name })
+	}
+	return nil
+}
+
+// Lookup returns the recipient named name, or every member of the group
+// @name.
+func (k *Keyring) Lookup(name string) ([]Recipient, error) {
+	names := []string{name}
+	if group, ok := strings.CutPrefix(name, "@"); ok {
+		members, exists := k.Groups[group]
+		if !exists {
+			return nil, fmt.Errorf("%w: no group named %q", ErrKeyring, name)
+		}
+		if len(members) == 0 {
+			return nil, fmt.Errorf("%w: group %q is empty", ErrKeyring, name)
+		}
+		names = members
+	}
+
+	var recipients []Recipient
+	for _, n := range names {
+		e := k.Entry(n)
+		if e == nil {
+			return nil, fmt.Errorf("%w: no key named %q", ErrKeyring, n)
+		}
+		r, err := ParseRecipient(e.Recipient)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n, err)
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// Identities returns every identity in the keyring, sorted by name.
+// passphrase is called with the name of an encrypted ssh key when a file
+// turns out to need it.
+func (k *Keyring) Identities(passphrase func(name string) func() ([]byte, error)) ([]Identity, error) {
+	var ids []Identity
+	for _, e := range k.Sorted() {
+		if e.Identity == "" {
+			continue
+		}
+		var pass func() ([]byte, error)
+		if passphrase != nil {
+			pass = passphrase(e.Name)
+		}
+		parsed, err := ParseIdentityFile([]byte(e.Identity), pass)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		ids = append(ids, parsed...)
+	}
+	return ids, nil
+}
+
+// Sorted returns the entries sorted by name.
+func (k *Keyring) Sorted() []KeyringEntry {
+	entries := slices.Clone(k.Entries)
+	slices.SortFunc(entries, func(a, b KeyringEntry) int { return strings.Compare(a.Name, b.Name) })
+	return entries
+}
+
+// IsKeyName reports whether s can name a key, or with an @ in front, a group.
+func IsKeyName(s string) bool {
+	return keyNameRE.MatchString(strings.TrimPrefix(s, "@"))
+}
+
+// Entry returns the key named name, or nil.
+func (k *Keyring) Entry(name string) *KeyringEntry {
+	for i := range k.Entries {
+		if k.Entries[i].Name == name {
+			return &k.Entries[i]
+		}
+	}
+	return nil
+}
+
+func (k *Keyring) checkNewName(name string) error {
+	if !keyNameRE.MatchString(name) {
+		return fmt.Errorf("%w: invalid name %q, use letters, digits, '.', '_' and '-'", ErrKeyring, name)
+	}
+	if IsRecipientKey(name) {
+		return fmt.Errorf("%w: invalid name %q, it starts like a public key", ErrKeyring, name)
+	}
+	if k.Entry(name) != nil {
+		return fmt.Errorf("%w: %q already exists, remove it first", ErrKeyring, name)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestKeyringRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go-enc", "keyring.genc")
+	pass := []byte(fuzzPass)
+	ctx := context.Background()
+
+	alice, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UpdateKeyring(ctx, pass, path, func(k *Keyring) error {
+		if err := k.AddIdentity("alice", []byte(alice.String()+"\n")); err != nil {
+			return err
+		}
+		if err := k.AddRecipient("bob", bob.Recipient().String()); err != nil {
+			return err
+		}
+		return k.AddToGroup("team", []string{"alice", "bob"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := OpenKeyring(pass, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		want []string
+	}{
+		{"alice", []string{alice.Recipient().String()}},
+		{"bob", []string{bob.Recipient().String()}},
+		{"@team", []string{alice.Recipient().String(), bob.Recipient().String()}},
+	} {
+		rs, err := k.Lookup(tc.name)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := recipientStrings(rs); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if ids, err := k.Identities(nil); err != nil || len(ids) != 1 {
+		t.Errorf("got %d identities, %v, want 1", len(ids), err)
+	}
+
+	if err := UpdateKeyring(ctx, pass, path, func(k *Keyring) error { return k.Remove("alice") }); err != nil {
+		t.Fatal(err)
+	}
+	if k, err = OpenKeyring(pass, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Lookup("alice"); !errors.Is(err, ErrKeyring) {
+		t.Errorf("removed key: got %v, want %v", err, ErrKeyring)
+	}
+	rs, err := k.Lookup("@team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recipientStrings(rs); len(got) != 1 || got[0] != bob.Recipient().String() {
+		t.Errorf("@team after remove: got %v", got)
+	}
+
+	if _, err := OpenKeyring([]byte("wrong horse battery staple"), path); !errors.Is(err, ErrAuth) {
+		t.Errorf("wrong passphrase: got %v, want %v", err, ErrAuth)
+	}
+}
+
+func TestKeyringNames(t *testing.T) {
+	bob, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := bob.Recipient().String()
+
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"bob", true},
+		{"bob.work-2", true},
+		{"genc1bob", false},
+		{"age1bob", false},
+		{"ssh-bob", false},
+		{"-bob", false},
+		{"@bob", false},
+		{"bob smith", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		k := &Keyring{Version: keyringVersion}
+		err := k.AddRecipient(tc.name, recipient)
+		if tc.ok && err != nil {
+			t.Errorf("%q: %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrKeyring) {
+			t.Errorf("%q: got %v, want %v", tc.name, err, ErrKeyring)
+		}
+	}
+
+	// the same name twice
+	k := &Keyring{Version: keyringVersion}
+	if err := k.AddRecipient("bob", recipient); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.AddRecipient("bob", recipient); !errors.Is(err, ErrKeyring) {
+		t.Errorf("duplicate: got %v, want %v", err, ErrKeyring)
+	}
+}
+
+func TestUpdateKeyringConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.genc")
+	pass := []byte(fuzzPass)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Go(func() {
+			id, err := GenerateX25519Identity()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = UpdateKeyring(context.Background(), pass, path, func(k *Keyring) error {
+				return k.AddRecipient(fmt.Sprintf("key%d", i), id.Recipient().String())
+			})
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := OpenKeyring(pass, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without the lock one update could read before another saved and lose it
+	if len(k.Entries) != len(errs) {
+		t.Errorf("got %d keys, want %d", len(k.Entries), len(errs))
+	}
+}
+
+func recipientStrings(rs []Recipient) []string {
+	var s []string
+	for _, r := range rs {
+		s = append(s, fmt.Sprint(r))
+	}
+	return s
+}
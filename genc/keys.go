@@ -0,0 +1,267 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// PassphraseForm is the unicode normalisation applied to a passphrase before
+// key derivation. New files always use FormNFC.
+type PassphraseForm = internal.PassForm
+
+const (
+	FormRaw = internal.PassRaw
+	FormNFC = internal.PassNFC
+	FormNFD = internal.PassNFD
+)
+
+// PassphraseMatch reports how DecryptStream matched the passphrase.
+type PassphraseMatch struct {
+	// Form is the normalisation that derived the right key.
+	Form PassphraseForm
+	// Fallback is set when the passphrase did not match in the form recorded
+	// by the file, but in another. Files written before passphrases were
+	// normalised record the raw form.
+	Fallback bool
+	// Used is set when the passphrase rather than an identity opened the file.
+	Used bool
+}
+
+// Recipient wraps the file key so that only the matching Identity can
+// unwrap it.
+type Recipient interface {
+	wrap(fileKey []byte) (internal.Stanza, error)
+	String() string
+}
+
+// Identity unwraps the file key from a stanza addressed to it.
+type Identity interface {
+	unwrap(st internal.Stanza) ([]byte, error)
+}
+
+// fileKeySize is the length of the random key of files with stanzas.
+const fileKeySize = 32
+
+// errWrongIdentity is returned by unwrap for stanzas meant for someone else.
+var errWrongIdentity = errors.New("stanza is for another identity")
+
+// sealFileKey encrypts fileKey with a key derived from secret. Every wrapping
+// key is used once, so the nonce is fixed.
+func sealFileKey(secret, salt []byte, info string, fileKey []byte) ([]byte, error) {
+	gcm, err := wrapGCM(secret, salt, info)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nil, make([]byte, gcm.NonceSize()), fileKey, nil), nil
+}
+
+// openFileKey reverses sealFileKey, failing with errWrongIdentity when the
+// secret does not match.
+func openFileKey(secret, salt []byte, info string, wrapped []byte) ([]byte, error) {
+	gcm, err := wrapGCM(secret, salt, info)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey, err := gcm.Open(nil, make([]byte, gcm.NonceSize()), wrapped, nil)
+	if err != nil {
+		return nil, errWrongIdentity
+	}
+	return fileKey, nil
+}
+
+// wrapGCM derives a one-time key wrapping cipher from secret with HKDF.
+func wrapGCM(secret, salt []byte, info string) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, secret, salt, info, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(key)
+
+	return newGCM(key)
+}
+
+// keyCandidates hands out the cipher for each form a passphrase may have
+// been encrypted with, deriving a key only once the previous one failed.
+type keyCandidates struct {
+	pass    []byte
+	keyfile []byte
+	header  fileHeader
+	forms   []PassphraseForm
+
+	// hash is a known key, used instead of pass when set
+	hash []byte
+
+	// ids are tried on stanzas instead of deriving a key from pass
+	ids     []Identity
+	stanzas []internal.Stanza
+
+	// derived are keys an agent derived from the passphrases it holds
+	derived []agentDerived
+
+	tried   [][]byte
+	cleanup []func()
+	match   PassphraseMatch
+}
+
+// passphraseKeys tries the form recorded in header first. Files that predate
+// normalisation fall back to the NFC and NFD forms of pass.
+func passphraseKeys(pass, keyfile []byte, header fileHeader) *keyCandidates {
+	forms := []PassphraseForm{header.passForm}
+	if header.passForm == FormRaw {
+		forms = append(forms, FormNFC, FormNFD)
+	}
+	return &keyCandidates{pass: pass, keyfile: keyfile, header: header, forms: forms}
+}
+
+// identityKeys unwraps the file key from the first stanza one of ids matches.
+func identityKeys(ids []Identity, header fileHeader) *keyCandidates {
+	return &keyCandidates{ids: ids, stanzas: header.stanzas}
+}
+
+// fixedKey yields a single cipher for an already derived hash.
+func fixedKey(hash []byte) *keyCandidates {
+	return &keyCandidates{hash: hash}
+}
+
+// next returns the cipher for the next candidate, or false once all have
+// been tried.
+func (k *keyCandidates) next() (cipher.AEAD, bool, error) {
+	if k.hash != nil {
+		hash := k.hash
+		k.hash = nil
+
+		gcm, err := newGCM(hash)
+		if err != nil {
+			return nil, false, err
+		}
+		return gcm, true, nil
+	}
+
+	if k.stanzas != nil {
+		stanzas := k.stanzas
+		k.stanzas = nil
+
+		fileKey, id, err := unwrapFileKey(k.ids, stanzas)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, ok := id.(*PassphraseIdentity); ok {
+			k.match = PassphraseMatch{Form: FormNFC, Used: true}
+		}
+		k.cleanup = append(k.cleanup, internal.ProtectKey(fileKey))
+
+		gcm, err := newGCM(fileKey)
+		if err != nil {
+			return nil, false, err
+		}
+		return gcm, true, nil
+	}
+
+	if len(k.derived) > 0 {
+		d := k.derived[0]
+		k.derived = k.derived[1:]
+		k.cleanup = append(k.cleanup, internal.ProtectKey(d.Key))
+
+		gcm, err := newGCM(d.Key)
+		if err != nil {
+			return nil, false, err
+		}
+
+		k.match = PassphraseMatch{Form: d.Form, Fallback: d.Form != k.header.passForm, Used: true}
+		return gcm, true, nil
+	}
+
+	for len(k.forms) > 0 {
+		form := k.forms[0]
+		k.forms = k.forms[1:]
+
+		// forms that produce the same bytes derive the same key
+		pass := internal.NormalizePass(k.pass, form)
+		if k.seen(pass) {
+			internal.Wipe(pass)
+			continue
+		}
+		k.tried = append(k.tried, pass)
+
+		hash := k.header.deriveKey(pass, k.keyfile)
+		k.cleanup = append(k.cleanup, internal.ProtectKey(hash))
+
+		gcm, err := newGCM(hash)
+		if err != nil {
+			return nil, false, err
+		}
+
+		k.match = PassphraseMatch{Form: form, Fallback: len(k.tried) > 1, Used: true}
+		return gcm, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// unwrapFileKey tries every identity on every stanza. Stanzas are
+// authenticated, so the first key unwrapped is the file key.
+func unwrapFileKey(ids []Identity, stanzas []internal.Stanza) ([]byte, Identity, error) {
+	for _, id := range ids {
+		for _, st := range stanzas {
+			fileKey, err := id.unwrap(st)
+			if errors.Is(err, errWrongIdentity) {
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(fileKey) != fileKeySize {
+				internal.Wipe(fileKey)
+				return nil, nil, fmt.Errorf("%w: invalid file key length %d", ErrInvalidHeader, len(fileKey))
+			}
+			return fileKey, id, nil
+		}
+	}
+	return nil, nil, ErrNoIdentity
+}
+
+func (k *keyCandidates) seen(pass []byte) bool {
+	for _, t := range k.tried {
+		if bytes.Equal(t, pass) {
+			return true
+		}
+	}
+	return false
+}
+
+// wipe clears every normalised passphrase and derived key.
+func (k *keyCandidates) wipe() {
+	for _, t := range k.tried {
+		internal.Wipe(t)
+	}
+	for _, f := range k.cleanup {
+		f()
+	}
+	for _, d := range k.derived {
+		internal.Wipe(d.Key)
+	}
+}
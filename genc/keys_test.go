@@ -0,0 +1,77 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+func TestPassphraseForms(t *testing.T) {
+	nfc := []byte("caf\u00e9 horse battery staple")  // é composed, as typed on Linux
+	nfd := []byte("cafe\u0301 horse battery staple") // e and an accent, as typed on macOS
+	plaintext := []byte("KEY=value\n")
+
+	// file encrypts plaintext with pass taken as form, under fuzzKDF
+	file := func(pass []byte, form internal.PassForm) []byte {
+		header := internal.HeaderV2{KDF: fuzzKDF, PassForm: form}
+		hash := internal.DeriveKey(pass, header.Salt[:], header.KDF)
+		var buf bytes.Buffer
+		if err := encryptStream(hash, header, nil, bytes.NewReader(plaintext), &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		pass []byte
+		want PassphraseMatch
+		err  error
+	}{
+		{name: "nfc file typed nfc", data: file(nfc, internal.PassNFC), pass: nfc, want: PassphraseMatch{Form: FormNFC, Used: true}},
+		{name: "nfc file typed nfd", data: file(nfc, internal.PassNFC), pass: nfd, want: PassphraseMatch{Form: FormNFC, Used: true}},
+		{name: "raw file typed alike", data: file(nfd, internal.PassRaw), pass: nfd, want: PassphraseMatch{Form: FormRaw, Used: true}},
+		{name: "raw nfd file typed nfc", data: file(nfd, internal.PassRaw), pass: nfc, want: PassphraseMatch{Form: FormNFD, Fallback: true, Used: true}},
+		{name: "raw nfc file typed nfd", data: file(nfc, internal.PassRaw), pass: nfd, want: PassphraseMatch{Form: FormNFC, Fallback: true, Used: true}},
+		{name: "raw file wrong passphrase", data: file(nfc, internal.PassRaw), pass: []byte("wrong horse battery staple"), err: ErrAuth},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var got PassphraseMatch
+			err := DecryptStream(tc.pass, bytes.NewReader(tc.data), &out, Options{PassphraseMatch: &got})
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.Equal(out.Bytes(), plaintext) {
+				t.Errorf("decrypted %q", out.Bytes())
+			}
+			if got != tc.want {
+				t.Errorf("matched %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,546 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/irrisdev/go-enc/internal"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Legacy formats DecryptStream reads but never writes: OpenPGP messages from
+// gpg --symmetric and files from openssl enc -aes-256-cbc -pbkdf2. Both are
+// only ever decrypted with a passphrase.
+
+const (
+	opensslMagic = "Salted__"
+	pgpArmor     = "-----BEGIN PGP MESSAGE-----"
+
+	// DefaultOpenSSLIterations is the pbkdf2 iteration count of openssl enc
+	// when -iter is not given.
+	DefaultOpenSSLIterations = 10000
+
+	// maxSKESKs bounds the session key packets of an OpenPGP message, each
+	// costs a key derivation, gpg --symmetric writes one
+	maxSKESKs = 2
+
+	// maxS2KWork is the most bytes the S2K specifiers of a message may hash
+	// together, as much as a single one can ask for
+	maxS2KWork = 65011712
+
+	// maxHeldBuffer bounds the plaintext held back when the output is
+	// streamed, until the OpenPGP integrity check or a signature passes
+	maxHeldBuffer = 64 << 20
+)
+
+var errHeldBuffer = errors.New("plaintext too large to hold back")
+
+// errOpenPGPAEAD refuses the AEAD messages newer gpg may write.
+var errOpenPGPAEAD = fmt.Errorf("%w: OpenPGP AEAD messages are not supported, they can be re-created with gpg --rfc4880", ErrFormat)
+
+// decryptLegacy decrypts an OpenPGP message or an openssl enc file with pass
+// as typed.
+func (o Options) decryptLegacy(pass []byte, reader *bufio.Reader, w io.Writer) error {
+	if len(pass) == 0 {
+		return fmt.Errorf("%w: file is encrypted with a passphrase", ErrAuth)
+	}
+
+	var err error
+	if isOpenSSL(reader) {
+		err = o.decryptOpenSSL(pass, reader, w)
+	} else {
+		err = o.decryptOpenPGP(pass, reader, w)
+	}
+	if err != nil {
+		return err
+	}
+
+	if o.PassphraseMatch != nil {
+		*o.PassphraseMatch = PassphraseMatch{Form: FormRaw, Used: true}
+	}
+	return nil
+}
+
+// isOpenSSL reports whether r starts like a salted openssl enc file.
+func isOpenSSL(r *bufio.Reader) bool {
+	magic, _ := r.Peek(len(opensslMagic))
+	return string(magic) == opensslMagic
+}
+
+// isOpenPGP reports whether r starts with an armored OpenPGP message or a
+// binary packet that begins an encrypted message.
+func isOpenPGP(r *bufio.Reader) bool {
+	if start, _ := r.Peek(len(pgpArmor)); string(start) == pgpArmor {
+		return true
+	}
+
+	b, err := r.Peek(1)
+	if err != nil || b[0]&0x80 == 0 {
+		return false
+	}
+
+	// tag 1 public key and tag 3 symmetric key encrypted session keys, in
+	// the new or the old packet format
+	tag := b[0] & 0x3f
+	if b[0]&0x40 == 0 {
+		tag = (b[0] >> 2) & 0x0f
+	}
+	return tag == 1 || tag == 3
+}
+
+// decryptOpenPGP decrypts a symmetrically encrypted OpenPGP message and
+// writes the content of its literal data packet to w. Messages without a
+// modification detection code are refused, a failed check is ErrAuth.
+func (o Options) decryptOpenPGP(pass []byte, reader *bufio.Reader, w io.Writer) error {
+	var r io.Reader = reader
+	if isArmored(reader) {
+		block, err := armor.Decode(reader)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+		if block.Type != "PGP MESSAGE" {
+			return fmt.Errorf("%w: armored %s is not a message", ErrInvalidHeader, block.Type)
+		}
+		r = block.Body
+	}
+
+	// the raw session key packets are kept to check their S2K specifiers,
+	// packets are read one at a time so the bytes of each are exact
+	rec := &pgpRecorder{r: r, on: true}
+
+	var skesks []*packet.SymmetricKeyEncrypted
+	var se *packet.SymmetricallyEncrypted
+	s2kWork := 0
+	for se == nil {
+		rec.buf = rec.buf[:0]
+		p, err := packet.Read(rec)
+		if err == io.EOF {
+			return fmt.Errorf("%w: no OpenPGP encrypted data", ErrInvalidHeader)
+		}
+
+		var unknown pgperrors.UnknownPacketTypeError
+		var unsupported pgperrors.UnsupportedError
+		switch {
+		case errors.As(err, &unknown):
+			continue
+		case errors.As(err, &unsupported):
+			// session key packets of newer versions are skipped like gpg
+			// skips what it cannot use, data packets are not
+			switch p.(type) {
+			case *packet.SymmetricKeyEncrypted:
+				continue
+			case *packet.AEADEncrypted:
+				return errOpenPGPAEAD
+			}
+			return pgpError(err)
+		case err != nil:
+			return pgpError(err)
+		}
+
+		switch p := p.(type) {
+		case *packet.SymmetricKeyEncrypted:
+			if p.Version != 4 {
+				continue
+			}
+			if len(skesks) == maxSKESKs {
+				return fmt.Errorf("%w: more than %d OpenPGP session key packets", ErrInvalidHeader, maxSKESKs)
+			}
+			count, err := s2kCount(rec.buf)
+			if err != nil {
+				return err
+			}
+			if s2kWork += count; s2kWork > maxS2KWork {
+				return fmt.Errorf("%w: OpenPGP S2K hashes %d bytes, at most %d are allowed", ErrKDFLimit, s2kWork, maxS2KWork)
+			}
+			skesks = append(skesks, p)
+		case *packet.EncryptedKey:
+			log.Printf("skipping OpenPGP session key encrypted to public key %X\n", p.KeyId)
+		case *packet.Marker, packet.Padding:
+		case *packet.SymmetricallyEncrypted:
+			if p.Version != 1 {
+				return errOpenPGPAEAD
+			}
+			se = p
+		case *packet.AEADEncrypted:
+			return errOpenPGPAEAD
+		default:
+			return fmt.Errorf("%w: unexpected OpenPGP packet %T", ErrInvalidHeader, p)
+		}
+	}
+
+	rec.on, rec.buf = false, nil
+
+	if len(skesks) == 0 {
+		return fmt.Errorf("%w: OpenPGP message is not encrypted with a passphrase", ErrFormat)
+	}
+	if !se.IntegrityProtected {
+		return fmt.Errorf("%w: OpenPGP message has no integrity protection (MDC)", ErrFormat)
+	}
+
+	contents, err := openPGPContents(pass, skesks, se)
+	if err != nil {
+		return err
+	}
+
+	// the plaintext would otherwise be passed on before the MDC is checked,
+	// so it is held back until then
+	dst := w
+	var held *heldWriter
+	if !discards(w) {
+		held = &heldWriter{}
+		defer held.wipe()
+		dst = held
+	}
+
+	writer := bufio.NewWriterSize(dst, internal.RWSize)
+	if err := copyLiteral(packet.NewReader(contents), literalWriter{writer}); err != nil {
+		if errors.Is(err, errHeldBuffer) {
+			return fmt.Errorf("%w: OpenPGP message is larger than %d MiB, decrypt it to a file instead", ErrFormat, maxHeldBuffer>>20)
+		}
+
+		// a wrong passphrase decrypts to garbage, only the MDC tells it
+		// apart from a message damaged inside
+		if !errors.Is(err, ErrWriteChunk) {
+			if _, err := io.Copy(io.Discard, contents); errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+			}
+			if contents.Close() != nil {
+				return fmt.Errorf("%w: OpenPGP integrity check failed", ErrAuth)
+			}
+		}
+		return err
+	}
+
+	// reads to the end and compares the MDC, only then is the plaintext
+	// known to be intact
+	if err := contents.Close(); err != nil {
+		return fmt.Errorf("%w: OpenPGP integrity check failed: %w", ErrAuth, err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	if held != nil {
+		if _, err := w.Write(held.buf); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+		}
+	}
+	return nil
+}
+
+// heldWriter keeps plaintext in memory, up to maxHeldBuffer, until it is
+// known to be intact.
+type heldWriter struct {
+	buf []byte
+}
+
+func (h *heldWriter) Write(p []byte) (int, error) {
+	need := len(h.buf) + len(p)
+	if need > maxHeldBuffer {
+		return 0, errHeldBuffer
+	}
+
+	// grown by hand so no copy of the plaintext is left unwiped
+	if need > cap(h.buf) {
+		grown := make([]byte, len(h.buf), max(need, 2*cap(h.buf)))
+		copy(grown, h.buf)
+		internal.Wipe(h.buf)
+		h.buf = grown
+	}
+	h.buf = append(h.buf, p...)
+	return len(p), nil
+}
+
+func (h *heldWriter) wipe() {
+	internal.Wipe(h.buf)
+}
+
+// pgpRecorder keeps what the packet reader reads while on, so the raw bytes
+// of the packet it parsed last can be looked at.
+type pgpRecorder struct {
+	r   io.Reader
+	on  bool
+	buf []byte
+}
+
+func (p *pgpRecorder) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if p.on {
+		p.buf = append(p.buf, b[:n]...)
+	}
+	return n, err
+}
+
+// s2kCount returns how many bytes the S2K specifier of a raw symmetric key
+// encrypted session key packet hashes. Simple and salted specifiers hash the
+// passphrase once and count as zero, Argon2 is refused.
+func s2kCount(raw []byte) (int, error) {
+	body, ok := pgpPacketBody(raw)
+	if !ok || len(body) < 3 {
+		return 0, fmt.Errorf("%w: malformed OpenPGP session key packet", ErrInvalidHeader)
+	}
+
+	// version, cipher, then the specifier: type, hash, 8 byte salt, count
+	switch body[2] {
+	case 0, 1:
+		return 0, nil
+	case 3:
+	default:
+		return 0, fmt.Errorf("%w: unsupported OpenPGP S2K type %d", ErrFormat, body[2])
+	}
+	if len(body) < 13 {
+		return 0, fmt.Errorf("%w: malformed OpenPGP S2K specifier", ErrInvalidHeader)
+	}
+	c := int(body[12])
+	return (16 + c&15) << (c>>4 + 6), nil
+}
+
+// pgpPacketBody strips the packet header from raw, in the new or the old
+// format. Session key packets never use partial lengths.
+func pgpPacketBody(raw []byte) ([]byte, bool) {
+	if len(raw) < 2 {
+		return nil, false
+	}
+
+	size := 0
+	if raw[0]&0x40 != 0 {
+		switch l := raw[1]; {
+		case l < 192:
+			size = 2
+		case l < 224:
+			size = 3
+		case l == 255:
+			size = 6
+		default:
+			return nil, false
+		}
+	} else {
+		size = []int{2, 3, 5, 1}[raw[0]&3]
+	}
+
+	if len(raw) < size {
+		return nil, false
+	}
+	return raw[size:], true
+}
+
+func isArmored(r *bufio.Reader) bool {
+	start, _ := r.Peek(len(pgpArmor))
+	return string(start) == pgpArmor
+}
+
+// openPGPContents derives the session key from pass with the first session
+// key packet that decrypts, gpg only ever writes one. A wrong passphrase only
+// shows once the data is read.
+func openPGPContents(pass []byte, skesks []*packet.SymmetricKeyEncrypted, se *packet.SymmetricallyEncrypted) (io.ReadCloser, error) {
+	var lastErr error
+	for _, ske := range skesks {
+		key, cipherFunc, err := ske.Decrypt(pass)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// the data packet can only be read once
+		contents, err := se.Decrypt(cipherFunc, key)
+		internal.Wipe(key)
+		if err != nil {
+			return nil, pgpError(err)
+		}
+		return contents, nil
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrAuth, lastErr)
+}
+
+// copyLiteral writes the body of the literal data packet, decompressing as
+// needed. Signatures inside the message are not checked. The MDC is only
+// checked at the end, malformed packets before it mean the decrypted data is
+// damaged.
+func copyLiteral(packets *packet.Reader, w io.Writer) error {
+	literal := false
+	for {
+		p, err := packets.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return contentError(err)
+		}
+
+		switch p := p.(type) {
+		case *packet.Compressed:
+			if err := packets.Push(p.Body); err != nil {
+				return contentError(err)
+			}
+		case *packet.LiteralData:
+			if literal {
+				return fmt.Errorf("%w: more than one OpenPGP literal data packet", ErrDecryptChunk)
+			}
+			literal = true
+			if _, err := io.Copy(w, p.Body); err != nil {
+				return contentError(err)
+			}
+		case *packet.OnePassSignature, *packet.Signature:
+			log.Printf("OpenPGP message is signed, the signature is not verified\n")
+		default:
+			return fmt.Errorf("%w: unexpected OpenPGP packet %T", ErrDecryptChunk, p)
+		}
+	}
+
+	if !literal {
+		return fmt.Errorf("%w: OpenPGP message has no literal data", ErrDecryptChunk)
+	}
+	return nil
+}
+
+// literalWriter marks errors of w so they are told apart from read errors.
+type literalWriter struct {
+	w io.Writer
+}
+
+func (l literalWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	return n, err
+}
+
+// contentError maps errors reading the decrypted packets.
+func contentError(err error) error {
+	switch {
+	case errors.Is(err, ErrWriteChunk):
+		return err
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+	}
+	return fmt.Errorf("%w: %w", ErrDecryptChunk, err)
+}
+
+// pgpError maps errors of the OpenPGP packet reader onto the errors of this
+// package.
+func pgpError(err error) error {
+	var structural pgperrors.StructuralError
+	var unsupported pgperrors.UnsupportedError
+	var signature pgperrors.SignatureError
+
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+	case errors.As(err, &structural):
+		return fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	case errors.As(err, &unsupported):
+		return fmt.Errorf("%w: %w", ErrFormat, err)
+	case errors.As(err, &signature):
+		return fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+	return fmt.Errorf("%w: %w", ErrReadChunk, err)
+}
+
+// decryptOpenSSL decrypts an openssl enc -aes-256-cbc -pbkdf2 file: the
+// magic, an 8 byte salt and the PKCS#7 padded ciphertext, with key and IV
+// from pbkdf2-sha256. The format has no MAC, a wrong passphrase or
+// iteration count is only noticed by the padding at the very end.
+func (o Options) decryptOpenSSL(pass []byte, reader *bufio.Reader, w io.Writer) error {
+	// there is nothing to check before the plaintext is passed on
+	if o.Streaming {
+		return fmt.Errorf("%w: openssl files are not authenticated, decrypt or import them to a file instead", ErrFormat)
+	}
+
+	prefix := make([]byte, len(opensslMagic)+8)
+	if err := readFull(reader, prefix); err != nil {
+		return fmt.Errorf("%w: %w", ErrReadHeader, err)
+	}
+	salt := prefix[len(opensslMagic):]
+
+	iter := o.OpenSSLIterations
+	if iter == 0 {
+		iter = DefaultOpenSSLIterations
+	}
+
+	km := pbkdf2.Key(pass, salt, iter, 32+aes.BlockSize, sha256.New)
+	defer internal.Wipe(km)
+
+	block, err := aes.NewCipher(km[:32])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNewCipher, err)
+	}
+	cbc := cipher.NewCBCDecrypter(block, km[32:])
+
+	log.Printf("openssl files are not authenticated, a tampered file may decrypt to altered data\n")
+
+	writer := bufio.NewWriterSize(w, internal.RWSize)
+	buf := make([]byte, internal.RWSize)
+
+	// the last block is held back until EOF to strip the padding
+	var held []byte
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: %w", ErrReadChunk, err)
+		}
+		if n%aes.BlockSize != 0 {
+			return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+		}
+
+		if n > 0 {
+			cbc.CryptBlocks(buf[:n], buf[:n])
+			if _, err := writer.Write(held); err != nil {
+				return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+			}
+			if _, err := writer.Write(buf[:n-aes.BlockSize]); err != nil {
+				return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+			}
+			held = append(held[:0], buf[n-aes.BlockSize:n]...)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if held == nil {
+		return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+	}
+
+	pad := int(held[len(held)-1])
+	if pad == 0 || pad > aes.BlockSize || !bytes.Equal(held[len(held)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return fmt.Errorf("%w: bad padding, wrong passphrase or iteration count", ErrAuth)
+	}
+
+	if _, err := writer.Write(held[:len(held)-pad]); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/ProtonMail/go-crypto/openpgp/s2k"
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// pgpMessage encrypts plaintext to fuzzPass with the given S2K count.
+func pgpMessage(t *testing.T, plaintext []byte, count int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := openpgp.SymmetricallyEncrypt(&buf, fuzzPass, nil, &packet.Config{S2KConfig: &s2k.Config{S2KMode: s2k.IteratedSaltedS2K, S2KCount: count}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// repeatSKESK repeats the leading session key packet of msg n times.
+func repeatSKESK(t *testing.T, msg []byte, n int) []byte {
+	t.Helper()
+
+	body, ok := pgpPacketBody(msg)
+	if !ok {
+		t.Fatal("malformed packet")
+	}
+	// gpg writes session key packets with a one byte length
+	size := len(msg) - len(body) + int(msg[1])
+
+	skesk := msg[:size]
+	return append(bytes.Repeat(skesk, n), msg[size:]...)
+}
+
+func TestOpenPGPSessionKeyLimits(t *testing.T) {
+	plaintext := []byte("KEY=value\n")
+
+	tests := []struct {
+		name    string
+		count   int
+		skesks  int
+		wantErr error
+	}{
+		{name: "one", count: 1024, skesks: 1},
+		{name: "two", count: 1024, skesks: 2},
+		{name: "too many", count: 1024, skesks: 3, wantErr: ErrInvalidHeader},
+		{name: "too much work", count: 65011712, skesks: 2, wantErr: ErrKDFLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := repeatSKESK(t, pgpMessage(t, plaintext, tt.count), tt.skesks)
+
+			var out bytes.Buffer
+			err := DecryptStream(fuzzPass, bytes.NewReader(msg), &out)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("DecryptStream: %v", err)
+				}
+				if !bytes.Equal(out.Bytes(), plaintext) {
+					t.Fatalf("got %q, want %q", out.Bytes(), plaintext)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenPGPHoldsBackTamperedData(t *testing.T) {
+	// more than the write buffer, so unheld output would reach out
+	plaintext := bytes.Repeat([]byte("KEY=value\n"), 4*internal.RWSize/10)
+	msg := pgpMessage(t, plaintext, 1024)
+
+	// the MDC is at the end, a flipped bit in it fails only that check
+	msg[len(msg)-5] ^= 1
+
+	var out bytes.Buffer
+	err := DecryptStream(fuzzPass, bytes.NewReader(msg), &out)
+	if !errors.Is(err, ErrAuth) {
+		t.Fatalf("got %v, want %v", err, ErrAuth)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("%d bytes written before the integrity check", out.Len())
+	}
+
+	out.Reset()
+	msg[len(msg)-5] ^= 1
+	if err := DecryptStream(fuzzPass, bytes.NewReader(msg), &out); err != nil {
+		t.Fatalf("DecryptStream: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), plaintext) {
+		t.Fatal("plaintext differs")
+	}
+}
+
+func TestOpenSSLStreamingRefused(t *testing.T) {
+	data := append([]byte(opensslMagic), make([]byte, 8+32)...)
+
+	var out bytes.Buffer
+	err := DecryptStream(fuzzPass, bytes.NewReader(data), &out, Options{Streaming: true})
+	if !errors.Is(err, ErrFormat) {
+		t.Fatalf("got %v, want %v", err, ErrFormat)
+	}
+}
+
+// TestOpenPGPFixtures decrypts messages written by gpg 2.2 --symmetric with
+// fuzzPass, of the plaintext below.
+func TestOpenPGPFixtures(t *testing.T) {
+	plaintext := []byte("KEY=value\nOTHER=\"two words\"\n")
+
+	tests := []struct {
+		name    string
+		file    string
+		pass    []byte
+		skesks  int
+		wantErr error
+	}{
+		{name: "mdc", file: "mdc.gpg"},
+		{name: "armored aes128", file: "mdc-armor.asc"},
+		{name: "uncompressed", file: "mdc-uncompressed.gpg"},
+		{name: "largest s2k count", file: "s2k-max.gpg"},
+		{name: "wrong passphrase", file: "mdc.gpg", pass: []byte("wrong"), wantErr: ErrAuth},
+		{name: "no mdc", file: "nomdc.gpg", wantErr: ErrFormat},
+		{name: "s2k over the bound", file: "s2k-max.gpg", skesks: 2, wantErr: ErrKDFLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := os.ReadFile(filepath.Join("testdata/openpgp", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.skesks > 0 {
+				msg = repeatSKESK(t, msg, tt.skesks)
+			}
+			pass := tt.pass
+			if pass == nil {
+				pass = fuzzPass
+			}
+
+			var out bytes.Buffer
+			err = DecryptStream(pass, bytes.NewReader(msg), &out)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("DecryptStream: %v", err)
+				}
+				if !bytes.Equal(out.Bytes(), plaintext) {
+					t.Fatalf("got %q, want %q", out.Bytes(), plaintext)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if out.Len() != 0 {
+				t.Fatalf("%d bytes written on error", out.Len())
+			}
+		})
+	}
+}
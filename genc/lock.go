@@ -0,0 +1,52 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// LockFiles takes the advisory locks Encrypt and Decrypt hold on their input
+// and output, for callers that read and write genc files themselves. Each path
+// is locked through a hidden sidecar .name.go-enc-lock file recording the
+// holder's pid.
+// Without wait a held lock fails with ErrLocked naming that pid, with wait it
+// is retried until free or until ctx is done.
+func LockFiles(ctx context.Context, wait bool, paths ...string) (unlock func(), err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	unlock, err = internal.LockFiles(ctx, wait, paths...)
+	if err != nil {
+		var locked *internal.LockedError
+		switch {
+		case errors.As(err, &locked):
+			return nil, fmt.Errorf("%w: %w", ErrLocked, err)
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("%w: %w", ErrInterrupted, err)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrCreateFile, err)
+	}
+
+	return unlock, nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import "github.com/irrisdev/go-enc/internal"
+
+// Passphrases are taken as []byte so callers can wipe them after use. Derived
+// keys are mlocked where permitted and wiped before the functions in this
+// package return. The helpers below let callers apply the same treatment to
+// their own secrets.
+
+// Wipe zeroes b.
+func Wipe(b []byte) {
+	internal.Wipe(b)
+}
+
+// LockMemory mlocks the pages holding b so they are never swapped out.
+// It fails when RLIMIT_MEMLOCK is exhausted or the platform lacks mlock.
+func LockMemory(b []byte) error {
+	return internal.LockMemory(b)
+}
+
+// UnlockMemory reverses LockMemory.
+func UnlockMemory(b []byte) error {
+	return internal.UnlockMemory(b)
+}
+
+// HardenProcess disables core dumps for the current process and, on Linux,
+// marks it non-dumpable so secrets cannot be read through ptrace or /proc.
+func HardenProcess() error {
+	return internal.HardenProcess()
+}
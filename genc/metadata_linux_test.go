@@ -0,0 +1,112 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestPreserveMetadata(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atime := time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	mtime := time.Date(2025, 6, 7, 8, 9, 10, 0, time.UTC)
+	xattr := []byte("rotated quarterly")
+
+	tests := []struct {
+		name     string
+		preserve bool
+	}{
+		{name: "default", preserve: false},
+		{name: "preserve", preserve: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "secrets")
+			if err := os.WriteFile(path, []byte("KEY=value\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, 0o640); err != nil {
+				t.Fatal(err)
+			}
+			withXattr := true
+			if err := unix.Setxattr(path, "user.note", xattr, 0); errors.Is(err, unix.ENOTSUP) {
+				withXattr = false
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			// only root can hand a file to another user
+			chowned := os.Getuid() == 0
+			if chowned {
+				if err := os.Chown(path, 65534, 65534); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.Chtimes(path, atime, mtime); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := Encrypt(nil, path, Options{Recipients: []Recipient{id.Recipient()}}); err != nil {
+				t.Fatal(err)
+			}
+			if info, err := os.Stat(path + ".genc"); err != nil || info.Mode().Perm() != 0o600 {
+				t.Errorf("encrypted file mode %v, %v, want 0600", info.Mode().Perm(), err)
+			}
+
+			out := filepath.Join(dir, "restored")
+			if err := Decrypt(nil, path+".genc", Options{Identities: []Identity{id}, Output: out, Preserve: tc.preserve}); err != nil {
+				t.Fatal(err)
+			}
+
+			var st unix.Stat_t
+			if err := unix.Stat(out, &st); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := st.Mode&0o777, map[bool]uint32{false: 0o600, true: 0o640}[tc.preserve]; got != want {
+				t.Errorf("mode %o, want %o", got, want)
+			}
+			restored := time.Unix(st.Mtim.Unix()).Equal(mtime) && time.Unix(st.Atim.Unix()).Equal(atime)
+			if restored != tc.preserve {
+				t.Errorf("times %v and %v restored %v", time.Unix(st.Atim.Unix()), time.Unix(st.Mtim.Unix()), restored)
+			}
+			if chowned {
+				if restored := st.Uid == 65534 && st.Gid == 65534; restored != tc.preserve {
+					t.Errorf("owner %d:%d restored %v", st.Uid, st.Gid, restored)
+				}
+			}
+			if withXattr {
+				buf := make([]byte, 64)
+				n, err := unix.Getxattr(out, "user.note", buf)
+				if restored := err == nil && bytes.Equal(buf[:n], xattr); restored != tc.preserve {
+					t.Errorf("xattr %q, %v restored %v", buf[:max(n, 0)], err, restored)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// Overwrite decides what happens when the output file already exists.
+type Overwrite int
+
+const (
+	// NoClobber refuses to replace an existing output file.
+	NoClobber Overwrite = iota
+	// Force replaces an existing output file.
+	Force
+)
+
+// Backup selects how an existing output file is backed up before it is replaced.
+// Requesting a backup implies the output may be replaced.
+type Backup int
+
+const (
+	BackupNone     Backup = iota
+	BackupSimple          // file.bak, replacing any earlier backup
+	BackupNumbered        // file.bak.1, file.bak.2, ...
+)
+
+// ParseBackup parses the CLI names none, simple and numbered.
+func ParseBackup(s string) (Backup, error) {
+	switch s {
+	case "none", "":
+		return BackupNone, nil
+	case "simple":
+		return BackupSimple, nil
+	case "numbered":
+		return BackupNumbered, nil
+	}
+	return BackupNone, fmt.Errorf("invalid backup mode %q, must be none, simple or numbered", s)
+}
+
+// Format is the container format new files are written in. Decryption
+// detects it.
+type Format int
+
+const (
+	// FormatGenc is the native format, the only one holding metadata.
+	FormatGenc Format = iota
+	// FormatAge is age v1, https://age-encryption.org/v1, readable by age
+	// and rage.
+	FormatAge
+)
+
+// ParseFormat parses the CLI names genc and age.
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "genc", "":
+		return FormatGenc, nil
+	case "age":
+		return FormatAge, nil
+	}
+	return FormatGenc, fmt.Errorf("invalid format %q, must be genc or age", s)
+}
+
+func (f Format) ext() string {
+	if f == FormatAge {
+		return ".age"
+	}
+	return ".genc"
+}
+
+// Options configures Encrypt and Decrypt. The zero value is the safe default.
+type Options struct {
+	// Output is the destination path. Encrypt defaults to filename + ".genc",
+	// Decrypt to filename without its extension, see PlainName.
+	Output string
+
+	// Format of the files Encrypt and EncryptStream write. age files store
+	// no metadata, cannot be sparse and cannot mix a passphrase with
+	// recipients.
+	Format Format
+
+	Overwrite Overwrite
+	Backup    Backup
+
+	// DeleteOrigin removes the plaintext after Encrypt, once the new file has
+	// been read back and verified. ShredPasses overwrites it first.
+	DeleteOrigin bool
+	ShredPasses  int
+
+	// Recipients encrypt to public keys, each able to decrypt on its own. A
+	// passphrase given as well is added as one more recipient, for recovery.
+	// Identities are tried on every key stanza of a file, before the
+	// passphrase.
+	Recipients []Recipient
+	Identities []Identity
+
+	// Keyfile is the digest of a keyfile from ReadKeyfile. Encrypting mixes
+	// it into the key derived from the passphrase and records that the file
+	// needs it, decrypting such a file fails with ErrKeyfile without it.
+	// Only genc passphrase files take a keyfile.
+	Keyfile []byte
+
+	// SignWith makes Encrypt sign the file it writes with Ed25519, in a
+	// signature file beside it named with SignatureExt. VerifyWith makes
+	// Decrypt and DecryptStream refuse files without a signature by one of
+	// its keys, nothing is written to w until it is checked.
+	// Signature is the signature file content for DecryptStream, which has
+	// no path to find it by, Decrypt reads it with ReadSignature.
+	SignWith   *SigningKey
+	VerifyWith []*VerifyKey
+	Signature  []byte
+
+	// Sparse stores runs of all-zero chunks as their length when encrypting,
+	// so sparse images do not grow. Where the zero runs are is then visible
+	// without the passphrase. Decrypt writes them back as holes.
+	Sparse bool
+
+	// Preserve restores the stored mode, ownership, timestamps and user
+	// extended attributes on the output of Decrypt.
+	Preserve bool
+
+	// Metadata is stored by EncryptStream when set and receives the stored
+	// metadata in DecryptStream. Encrypt captures it from the input file.
+	Metadata *Metadata
+
+	// PassphraseMatch receives how the passphrase was matched by Decrypt and
+	// DecryptStream.
+	PassphraseMatch *PassphraseMatch
+
+	// MaxKDFMemory is the most argon2id memory, in KiB, a file may ask for
+	// when decrypting. Zero means 1 GiB.
+	MaxKDFMemory uint32
+
+	// OpenSSLIterations is the pbkdf2 iteration count of openssl enc files,
+	// which do not record it. Zero means DefaultOpenSSLIterations.
+	OpenSSLIterations int
+
+	// Streaming tells DecryptStream that w passes the plaintext on as it is
+	// written, to a terminal, pipe or process, rather than to a file only
+	// kept once decryption succeeded. openssl files, which have no
+	// integrity check, are then refused with ErrFormat. OpenPGP messages and
+	// signed files are always held, up to 64 MiB, until their integrity
+	// check or signature passes, except by Decrypt and Import, which discard
+	// their output on error.
+	Streaming bool
+
+	// FollowSymlinks allows the input to be a symlink, the file it points
+	// to is read and, with DeleteOrigin, removed along with the link.
+	FollowSymlinks bool
+
+	// Wait blocks until the locks on the input and output are free instead
+	// of failing with ErrLocked.
+	Wait bool
+
+	// Context stops the operation between reads once it is done. The error
+	// then wraps ErrInterrupted and any partial output has been removed.
+	Context context.Context
+}
+
+func getOptions(opts []Options) Options {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return Options{}
+}
+
+func (o Options) encryptOutput(filename string) string {
+	if o.Output != "" {
+		return o.Output
+	}
+	return filename + o.Format.ext()
+}
+
+func (o Options) decryptOutput(filename string) string {
+	if o.Output != "" {
+		return o.Output
+	}
+	return PlainName(filename)
+}
+
+// Extensions are the file extensions Decrypt recognises, of genc, age,
+// OpenPGP and openssl enc files.
+var Extensions = []string{".genc", ".age", ".gpg", ".pgp", ".asc", ".enc"}
+
+// PlainName returns filename without one of Extensions, or unchanged.
+func PlainName(filename string) string {
+	for _, ext := range Extensions {
+		if name, ok := strings.CutSuffix(filename, ext); ok {
+			return name
+		}
+	}
+	return filename
+}
+
+// interrupted returns an ErrInterrupted error once o.Context is done.
+func (o Options) interrupted() error {
+	if o.Context == nil || o.Context.Err() == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInterrupted, context.Cause(o.Context))
+}
+
+// reader wraps r so reads fail once o.Context is done.
+func (o Options) reader(r io.Reader) io.Reader {
+	if o.Context == nil {
+		return r
+	}
+	return contextReader{o, r}
+}
+
+type contextReader struct {
+	o Options
+	r io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.o.interrupted(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// replaces reports whether an existing output may be replaced.
+func (o Options) replaces() bool {
+	return o.Overwrite == Force || o.Backup != BackupNone
+}
+
+// checkOutput fails early when path exists and may not be replaced.
+func (o Options) checkOutput(path string) error {
+	if err := o.checkOutputPath(path); err != nil {
+		return err
+	}
+	if !o.replaces() && internal.FileExists(path) {
+		return fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	return nil
+}
+
+// commit backs up any existing output and moves the finished file into place.
+// It must only be called once the output is complete and authenticated.
+func (o Options) commit(f *internal.AtomicFile) error {
+	path := f.Path()
+
+	// last chance to stop, an existing output is never touched before this
+	if err := o.interrupted(); err != nil {
+		f.Abort()
+		return err
+	}
+
+	if !o.replaces() {
+		if err := f.CommitNoReplace(); err != nil {
+			if os.IsExist(err) {
+				return fmt.Errorf("%w: %s", ErrExists, path)
+			}
+			return err
+		}
+		return nil
+	}
+
+	if o.Backup != BackupNone && internal.FileExists(path) {
+		backup, err := internal.BackupPath(path, o.Backup == BackupNumbered)
+		if err != nil {
+			f.Abort()
+			return fmt.Errorf("%w: %w", ErrBakFile, err)
+		}
+
+		log.Printf("output file %s already exists, creating backup at: %s\n", filepath.Base(path), backup)
+
+		if err := internal.CopyFile(path, backup); err != nil {
+			f.Abort()
+			return fmt.Errorf("%w: %w", ErrBakFile, err)
+		}
+	}
+
+	return f.Commit()
+}
+
+// discards reports whether w is the temporary output of Decrypt or Import,
+// which is only kept once decryption succeeded, so plaintext may reach it
+// before it is authenticated.
+func discards(w io.Writer) bool {
+	switch w.(type) {
+	case *internal.AtomicFile, aborted:
+		return true
+	}
+	return false
+}
+
+// aborted marks a writer whose output is thrown away when decryption fails,
+// like the pipe Import encrypts from.
+type aborted struct {
+	io.Writer
+}
@@ -0,0 +1,148 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDoesNotClobber(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets")
+	if err := os.WriteFile(path, []byte("KEY=value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".genc", []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Encrypt(nil, path, Options{Recipients: []Recipient{id.Recipient()}})
+	if !errors.Is(err, ErrExists) {
+		t.Fatalf("got %v, want %v", err, ErrExists)
+	}
+
+	got, err := os.ReadFile(path + ".genc")
+	if err != nil || string(got) != "old" {
+		t.Fatalf("existing output changed to %q: %v", got, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("left behind %v: %v", entries, err)
+	}
+}
+
+func TestDecryptOverwrite(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		existing map[string]string // beside the output, by suffix
+		want     map[string]string // every file left beside it, by suffix
+		err      error
+	}{
+		{
+			name: "no clobber",
+			want: map[string]string{"": "old"},
+			err:  ErrExists,
+		},
+		{
+			name: "force",
+			opts: Options{Overwrite: Force},
+			want: map[string]string{"": "new"},
+		},
+		{
+			name:     "simple backup replaces the last",
+			opts:     Options{Backup: BackupSimple},
+			existing: map[string]string{".bak": "older"},
+			want:     map[string]string{"": "new", ".bak": "old"},
+		},
+		{
+			name:     "numbered backup",
+			opts:     Options{Backup: BackupNumbered},
+			existing: map[string]string{".bak.1": "older"},
+			want:     map[string]string{"": "new", ".bak.1": "older", ".bak.2": "old"},
+		},
+		{
+			name: "no backup before authentication",
+			opts: Options{Backup: BackupSimple, Identities: []Identity{other}},
+			want: map[string]string{"": "old"},
+			err:  ErrNoIdentity,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			out := filepath.Join(dir, "secrets")
+			enc := out + ".genc"
+
+			var buf strings.Builder
+			if err := EncryptStream(nil, strings.NewReader("new"), &buf, Options{Recipients: []Recipient{id.Recipient()}}); err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]string{enc: buf.String(), out: "old"}
+			for suffix, data := range tc.existing {
+				files[out+suffix] = data
+			}
+			for path, data := range files {
+				if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			opts := tc.opts
+			if opts.Identities == nil {
+				opts.Identities = []Identity{id}
+			}
+			err := Decrypt(nil, enc, opts)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tc.want)+1 {
+				t.Errorf("left %d files, want %d", len(entries), len(tc.want)+1)
+			}
+			for suffix, want := range tc.want {
+				got, err := os.ReadFile(out + suffix)
+				if err != nil || string(got) != want {
+					t.Errorf("secrets%s is %q, %v, want %q", suffix, got, err, want)
+				}
+			}
+		})
+	}
+}
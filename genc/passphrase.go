@@ -0,0 +1,124 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"fmt"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// binds wrapping keys to passphrase stanzas
+const passphraseInfo = "go-enc/passphrase"
+
+// PassphraseRecipient wraps the file key with a key derived from a
+// passphrase, so a file encrypted to public keys can also be recovered with
+// it. The passphrase is used in its NFC form.
+type PassphraseRecipient struct {
+	pass []byte
+}
+
+// PassphraseIdentity unwraps passphrase stanzas.
+type PassphraseIdentity struct {
+	pass []byte
+
+	// maxMemory bounds the kdf parameters a stanza may ask for, in KiB
+	maxMemory uint32
+
+	// form is the normalisation that opened an age scrypt stanza
+	form PassphraseForm
+}
+
+// NewPassphraseRecipient returns a recipient for pass. pass is not copied.
+func NewPassphraseRecipient(pass []byte) *PassphraseRecipient {
+	return &PassphraseRecipient{pass: pass}
+}
+
+// NewPassphraseIdentity returns an identity for pass. pass is not copied.
+func NewPassphraseIdentity(pass []byte) *PassphraseIdentity {
+	return &PassphraseIdentity{pass: pass}
+}
+
+func (r *PassphraseRecipient) String() string {
+	return "passphrase"
+}
+
+func (r *PassphraseRecipient) wrap(fileKey []byte) (internal.Stanza, error) {
+	salt, err := internal.GenerateSalt16()
+	if err != nil {
+		return internal.Stanza{}, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+	kdf := internal.DefaultKDFParams()
+
+	key := passphraseStanzaKey(r.pass, salt, kdf)
+	defer internal.Wipe(key)
+
+	wrapped, err := sealFileKey(key, salt, passphraseInfo, fileKey)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+
+	body := append(salt, internal.EncodeKDFParams(kdf)...)
+	return internal.Stanza{Type: internal.StanzaPassphrase, Body: append(body, wrapped...)}, nil
+}
+
+func (i *PassphraseIdentity) unwrap(st internal.Stanza) ([]byte, error) {
+	salt, kdf, wrapped, err := passphraseStanza(st, i.maxMemory)
+	if err != nil {
+		return nil, err
+	}
+
+	key := passphraseStanzaKey(i.pass, salt, kdf)
+	defer internal.Wipe(key)
+
+	return openFileKey(key, salt, passphraseInfo, wrapped)
+}
+
+// passphraseStanza splits a passphrase stanza into its salt, kdf parameters
+// and wrapped file key, allowing up to maxMemory KiB or the default when zero.
+func passphraseStanza(st internal.Stanza, maxMemory uint32) ([]byte, internal.KDFParams, []byte, error) {
+	var kdf internal.KDFParams
+	if st.Type != internal.StanzaPassphrase {
+		return nil, kdf, nil, errWrongIdentity
+	}
+	if len(st.Body) != 16+internal.KDFParamsSize+fileKeySize+internal.GCMTagSize {
+		return nil, kdf, nil, fmt.Errorf("%w: invalid passphrase stanza length %d", ErrInvalidHeader, len(st.Body))
+	}
+
+	kdf, err := internal.DecodeKDFParams(st.Body[16 : 16+internal.KDFParamsSize])
+	if err != nil {
+		return nil, kdf, nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+
+	if maxMemory == 0 {
+		maxMemory = internal.DefaultMaxKDFMemory
+	}
+	if err := internal.CheckKDFParams(kdf, maxMemory); err != nil {
+		return nil, kdf, nil, fmt.Errorf("%w: %w", ErrKDFLimit, err)
+	}
+
+	return st.Body[:16], kdf, st.Body[16+internal.KDFParamsSize:], nil
+}
+
+// passphraseStanzaKey derives the wrapping key from the NFC form of pass.
+func passphraseStanzaKey(pass, salt []byte, kdf internal.KDFParams) []byte {
+	normalized := internal.NormalizePass(pass, internal.PassNFC)
+	defer internal.Wipe(normalized)
+
+	return internal.DeriveKey(normalized, salt, kdf)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// The file policy of Encrypt and Decrypt: inputs must be regular files, a
+// symlink is only followed with Options.FollowSymlinks, and outputs are never
+// written through symlinks. Every non-obvious decision is logged.
+
+// resolveInput applies the symlink policy to filename and returns the path of
+// the regular file to read together with its info.
+func (o Options) resolveInput(filename string) (string, fs.FileInfo, error) {
+	info, err := os.Lstat(filename)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+
+	path := filename
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if !o.FollowSymlinks {
+			return "", nil, fmt.Errorf("%w: %s", ErrSymlink, filename)
+		}
+
+		path, err = filepath.EvalSymlinks(filename)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+		}
+		if info, err = os.Lstat(path); err != nil {
+			return "", nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+		}
+
+		log.Printf("following symlink %s -> %s\n", filename, path)
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", nil, fmt.Errorf("%w: %s is a %s", ErrSpecialFile, filename, fileKind(info.Mode()))
+	}
+
+	return path, info, nil
+}
+
+// openInput opens the file resolveInput inspected, making sure it was not
+// swapped for a symlink or another file in between.
+func openInput(path string, info fs.FileInfo) (*os.File, error) {
+	f, err := internal.OpenNoFollow(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+
+	opened, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	if !os.SameFile(info, opened) {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s was replaced while being opened", ErrOpenFile, path)
+	}
+
+	return f, nil
+}
+
+// checkRemovable refuses to delete an original whose content stays reachable
+// through other hard links, unless it is shredded, which destroys the content
+// for every link.
+func checkRemovable(path string, info fs.FileInfo, shredPasses int) error {
+	links := internal.LinkCount(info)
+	if links < 2 {
+		return nil
+	}
+
+	if shredPasses == 0 {
+		return fmt.Errorf("%w: %s has %d links, removing it leaves the plaintext reachable, shred it instead", ErrHardLinks, path, links)
+	}
+
+	log.Printf("%s has %d links, shredding destroys the content for all of them\n", path, links)
+	return nil
+}
+
+// checkOutputPath refuses outputs that are special files, or symlinks in
+// directories other users can write to. Elsewhere an existing symlink is
+// replaced by the output, its target is never written.
+func (o Options) checkOutputPath(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		if internal.SharedDir(filepath.Dir(path)) {
+			return fmt.Errorf("%w: output %s is in a shared directory", ErrSymlink, path)
+		}
+		if o.replaces() {
+			log.Printf("output %s is a symlink, replacing the link, not its target\n", path)
+		}
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("%w: output %s is a %s", ErrSpecialFile, path, fileKind(info.Mode()))
+	}
+
+	return nil
+}
+
+func fileKind(mode fs.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "directory"
+	case mode&fs.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "block device"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	}
+	return "special file"
+}
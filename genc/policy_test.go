@@ -0,0 +1,220 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestFilePolicy(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := []Recipient{id.Recipient()}
+
+	write := func(t *testing.T, path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := func(t *testing.T, target, path string) {
+		t.Helper()
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		// setup prepares dir and returns the file to encrypt and how
+		setup func(t *testing.T, dir string) (string, Options)
+		err   error
+		// left lists what must be in dir afterwards, with "" for a new
+		// encrypted file and "->" in front of a symlink's target
+		left map[string]string
+	}{
+		{
+			name: "regular file",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "secrets"), "KEY=value\n")
+				return filepath.Join(dir, "secrets"), Options{Recipients: to}
+			},
+			left: map[string]string{"secrets": "KEY=value\n", "secrets.genc": ""},
+		},
+		{
+			name: "symlink refused",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "target"), "KEY=value\n")
+				link(t, "target", filepath.Join(dir, "secrets"))
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, DeleteOrigin: true}
+			},
+			err:  ErrSymlink,
+			left: map[string]string{"target": "KEY=value\n", "secrets": "->target"},
+		},
+		{
+			name: "symlink followed and removed with its target",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "target"), "KEY=value\n")
+				link(t, "target", filepath.Join(dir, "secrets"))
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, FollowSymlinks: true, DeleteOrigin: true}
+			},
+			left: map[string]string{"secrets.genc": ""},
+		},
+		{
+			name: "named pipe",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				if err := unix.Mkfifo(filepath.Join(dir, "secrets"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return filepath.Join(dir, "secrets"), Options{Recipients: to}
+			},
+			err:  ErrSpecialFile,
+			left: map[string]string{"secrets": "|"},
+		},
+		{
+			name: "directory",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				return dir, Options{Recipients: to, Output: filepath.Join(dir, "out.genc")}
+			},
+			err:  ErrSpecialFile,
+			left: map[string]string{},
+		},
+		{
+			name: "hard link kept",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "secrets"), "KEY=value\n")
+				if err := os.Link(filepath.Join(dir, "secrets"), filepath.Join(dir, "other")); err != nil {
+					t.Fatal(err)
+				}
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, DeleteOrigin: true}
+			},
+			err:  ErrHardLinks,
+			left: map[string]string{"secrets": "KEY=value\n", "other": "KEY=value\n"},
+		},
+		{
+			name: "hard link shredded",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "secrets"), "KEY=value\n")
+				if err := os.Link(filepath.Join(dir, "secrets"), filepath.Join(dir, "other")); err != nil {
+					t.Fatal(err)
+				}
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, DeleteOrigin: true, ShredPasses: 1}
+			},
+			// the other name now holds random bytes
+			left: map[string]string{"other": "?", "secrets.genc": ""},
+		},
+		{
+			name: "output symlink in a shared directory",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				if err := os.Chmod(dir, 0o1777); err != nil {
+					t.Fatal(err)
+				}
+				write(t, filepath.Join(dir, "secrets"), "KEY=value\n")
+				write(t, filepath.Join(dir, "victim"), "keep")
+				link(t, "victim", filepath.Join(dir, "secrets.genc"))
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, Overwrite: Force}
+			},
+			err:  ErrSymlink,
+			left: map[string]string{"secrets": "KEY=value\n", "victim": "keep", "secrets.genc": "->victim"},
+		},
+		{
+			name: "output symlink replaced, not its target",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "secrets"), "KEY=value\n")
+				write(t, filepath.Join(dir, "victim"), "keep")
+				link(t, "victim", filepath.Join(dir, "secrets.genc"))
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, Overwrite: Force}
+			},
+			left: map[string]string{"secrets": "KEY=value\n", "victim": "keep", "secrets.genc": ""},
+		},
+		{
+			name: "output named pipe",
+			setup: func(t *testing.T, dir string) (string, Options) {
+				write(t, filepath.Join(dir, "secrets"), "KEY=value\n")
+				if err := unix.Mkfifo(filepath.Join(dir, "secrets.genc"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return filepath.Join(dir, "secrets"), Options{Recipients: to, Overwrite: Force}
+			},
+			err:  ErrSpecialFile,
+			left: map[string]string{"secrets": "KEY=value\n", "secrets.genc": "|"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename, opts := tc.setup(t, dir)
+
+			if err := Encrypt(nil, filename, opts); !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(tc.left) {
+				t.Errorf("left %v, want %d files", entries, len(tc.left))
+			}
+			for name, want := range tc.left {
+				path := filepath.Join(dir, name)
+				info, err := os.Lstat(path)
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					continue
+				}
+				switch {
+				case want == "|":
+					if info.Mode()&os.ModeNamedPipe == 0 {
+						t.Errorf("%s is no longer a named pipe", name)
+					}
+				case len(want) > 2 && want[:2] == "->":
+					if target, err := os.Readlink(path); err != nil || target != want[2:] {
+						t.Errorf("%s points to %q, %v, want %q", name, target, err, want[2:])
+					}
+				case want == "":
+					data, err := os.ReadFile(path)
+					if err != nil || !info.Mode().IsRegular() {
+						t.Fatalf("%s: %v", name, err)
+					}
+					if _, err := Inspect(bytes.NewReader(data)); err != nil {
+						t.Errorf("%s is not encrypted: %v", name, err)
+					}
+				case want == "?":
+					if data, err := os.ReadFile(path); err != nil || string(data) == "KEY=value\n" {
+						t.Errorf("%s still holds the plaintext: %v", name, err)
+					}
+				default:
+					if data, err := os.ReadFile(path); err != nil || string(data) != want {
+						t.Errorf("%s holds %q, %v, want %q", name, data, err, want)
+					}
+				}
+			}
+		})
+	}
+}
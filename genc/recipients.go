@@ -0,0 +1,128 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ParseRecipient parses a recipient of any supported type, including an
+// ssh public key in authorized_keys form.
+func ParseRecipient(s string) (Recipient, error) {
+	switch {
+	case strings.HasPrefix(s, x25519RecipientHRP+"1"), strings.HasPrefix(s, ageRecipientHRP+"1"):
+		return ParseX25519Recipient(s)
+	case strings.Contains(s, "ssh-"), isAuthorizedKey(s):
+		return ParseSSHRecipient(s)
+	}
+	return nil, fmt.Errorf("%w: unknown recipient type %q", ErrRecipient, s)
+}
+
+// IsRecipientKey reports whether s starts like one of the public keys
+// ParseRecipient knows, rather than like a key name.
+func IsRecipientKey(s string) bool {
+	return strings.HasPrefix(s, x25519RecipientHRP+"1") || strings.HasPrefix(s, ageRecipientHRP+"1") ||
+		strings.HasPrefix(s, "ssh-") || isAuthorizedKey(s)
+}
+
+// isAuthorizedKey reports whether s is an ssh public key of any type.
+func isAuthorizedKey(s string) bool {
+	_, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s))
+	return err == nil
+}
+
+// ParseRecipients reads a recipients file, such as authorized_keys: one
+// recipient per line, blank lines and lines starting with # are ignored.
+func ParseRecipients(r io.Reader) ([]Recipient, error) {
+	var recipients []Recipient
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rec, err := ParseRecipient(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		recipients = append(recipients, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("%w: no recipients found", ErrRecipient)
+	}
+	return recipients, nil
+}
+
+// ParseIdentityFile parses the content of an identity file, or of an ssh
+// private key, for which passphrase is called when it is encrypted.
+func ParseIdentityFile(data []byte, passphrase func() ([]byte, error)) ([]Identity, error) {
+	if IsSSHPrivateKey(data) {
+		id, err := ParseSSHIdentity(data, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []Identity{id}, nil
+	}
+	return ParseIdentities(bytes.NewReader(data))
+}
+
+// ParseIdentities reads an identity file: one identity per line, blank lines
+// and lines starting with # are ignored.
+func ParseIdentities(r io.Reader) ([]Identity, error) {
+	var ids []Identity
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// errors never repeat the line, it is a secret
+		switch {
+		case strings.HasPrefix(line, x25519IdentityHRP+"1"), strings.HasPrefix(line, ageIdentityHRP+"1"):
+			id, err := ParseX25519Identity(line)
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d is malformed", ErrIdentity, n)
+			}
+			ids = append(ids, id)
+		default:
+			return nil, fmt.Errorf("%w: line %d: unknown identity type", ErrIdentity, n)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no identities found", ErrIdentity)
+	}
+	return ids, nil
+}
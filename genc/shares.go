@@ -0,0 +1,294 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+const (
+	shareHRP = "GENC-SHARE-"
+
+	// binds wrapping keys to split secrets
+	sharesInfo = "go-enc/shares"
+
+	splitIDSize = 16
+	shareSize   = splitIDSize + 3 + fileKeySize
+)
+
+// SplitRecipient wraps the file key with a random secret that is split into
+// shares, any threshold of which recover it. The same shares open every file
+// encrypted to the same SplitRecipient.
+type SplitRecipient struct {
+	id        [splitIDSize]byte
+	threshold int
+	secret    []byte
+	shares    []*Share
+}
+
+// Share is one share of a split secret, written as GENC-SHARE-1... The
+// bech32 checksum catches typos, the split id mixing up shares of different
+// splits.
+type Share struct {
+	id        [splitIDSize]byte
+	threshold int
+	total     int
+	index     int
+	value     []byte
+}
+
+// ShareIdentity unwraps shares stanzas with the secret recovered from
+// enough shares of one split.
+type ShareIdentity struct {
+	id        [splitIDSize]byte
+	threshold int
+	total     int
+	secret    []byte
+}
+
+// NewSplitRecipient generates a secret and splits it into total shares, any
+// threshold of which recover it.
+func NewSplitRecipient(threshold, total int) (*SplitRecipient, error) {
+	if threshold < 1 || threshold > total || total > 255 {
+		return nil, fmt.Errorf("%w: cannot split into %d of %d shares, need 1 <= threshold <= total <= 255", ErrRecipient, threshold, total)
+	}
+
+	r := &SplitRecipient{threshold: threshold, secret: make([]byte, fileKeySize)}
+	if _, err := rand.Read(r.id[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+	if _, err := rand.Read(r.secret); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+
+	values, err := internal.SplitSecret(r.secret, threshold, total)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRecipient, err)
+	}
+	for i, v := range values {
+		r.shares = append(r.shares, &Share{id: r.id, threshold: threshold, total: total, index: i + 1, value: v})
+	}
+
+	return r, nil
+}
+
+// Shares returns the shares, to be handed out before anything is encrypted.
+func (r *SplitRecipient) Shares() []*Share {
+	return r.shares
+}
+
+func (r *SplitRecipient) String() string {
+	return fmt.Sprintf("%d of %d shares", r.threshold, len(r.shares))
+}
+
+func (r *SplitRecipient) wrap(fileKey []byte) (internal.Stanza, error) {
+	salt, err := internal.GenerateSalt16()
+	if err != nil {
+		return internal.Stanza{}, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+
+	wrapped, err := sealFileKey(r.secret, shareSalt(r.id, salt), sharesInfo, fileKey)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+
+	body := append(r.id[:splitIDSize:splitIDSize], byte(r.threshold), byte(len(r.shares)))
+	body = append(body, salt...)
+	return internal.Stanza{Type: internal.StanzaShares, Body: append(body, wrapped...)}, nil
+}
+
+// ParseShare parses a GENC-SHARE-1... string.
+func ParseShare(s string) (*Share, error) {
+	// errors never repeat the share, it is part of a secret
+	hrp, data, err := internal.Bech32Decode(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrShare, err)
+	}
+	defer internal.Wipe(data)
+
+	if hrp != strings.ToLower(shareHRP) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrShare, hrp)
+	}
+	if len(data) != shareSize {
+		return nil, fmt.Errorf("%w: invalid length %d", ErrShare, len(data))
+	}
+
+	sh := &Share{
+		threshold: int(data[splitIDSize]),
+		total:     int(data[splitIDSize+1]),
+		index:     int(data[splitIDSize+2]),
+		value:     bytes.Clone(data[splitIDSize+3:]),
+	}
+	copy(sh.id[:], data)
+
+	if sh.threshold < 1 || sh.threshold > sh.total || sh.index < 1 || sh.index > sh.total {
+		return nil, fmt.Errorf("%w: share %d of %d with threshold %d is impossible", ErrShare, sh.index, sh.total, sh.threshold)
+	}
+	return sh, nil
+}
+
+// ParseShares reads a share file: one share per line, blank lines and lines
+// starting with # are ignored.
+func ParseShares(r io.Reader) ([]*Share, error) {
+	var shares []*Share
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sh, err := ParseShare(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		shares = append(shares, sh)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("%w: no shares found", ErrShare)
+	}
+	return shares, nil
+}
+
+func (s *Share) String() string {
+	data := append(s.id[:splitIDSize:splitIDSize], byte(s.threshold), byte(s.total), byte(s.index))
+	str, _ := internal.Bech32Encode(shareHRP, append(data, s.value...))
+	return str
+}
+
+// Index returns the number of the share, from 1 to Total.
+func (s *Share) Index() int {
+	return s.index
+}
+
+// Threshold returns how many shares recover the secret.
+func (s *Share) Threshold() int {
+	return s.threshold
+}
+
+// Total returns how many shares the secret was split into.
+func (s *Share) Total() int {
+	return s.total
+}
+
+// NewShareIdentity recovers the secret from shares, which must all come from
+// the same split and be at least its threshold. Shares beyond the threshold
+// are checked against the others.
+func NewShareIdentity(shares []*Share) (*ShareIdentity, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("%w: no shares given", ErrShare)
+	}
+
+	first := shares[0]
+	byIndex := make(map[int]*Share)
+	var unique []*Share
+
+	for _, sh := range shares {
+		if sh.id != first.id {
+			return nil, fmt.Errorf("%w: shares are from different splits", ErrShare)
+		}
+		if sh.threshold != first.threshold || sh.total != first.total {
+			return nil, fmt.Errorf("%w: shares disagree on the threshold", ErrShare)
+		}
+		if prev, ok := byIndex[sh.index]; ok {
+			if subtle.ConstantTimeCompare(prev.value, sh.value) != 1 {
+				return nil, fmt.Errorf("%w: two different shares numbered %d", ErrShare, sh.index)
+			}
+			continue
+		}
+		byIndex[sh.index] = sh
+		unique = append(unique, sh)
+	}
+
+	if len(unique) < first.threshold {
+		return nil, fmt.Errorf("%w: %d different shares given, %d needed", ErrShare, len(unique), first.threshold)
+	}
+
+	xs := make([]byte, first.threshold)
+	ys := make([][]byte, first.threshold)
+	for i, sh := range unique[:first.threshold] {
+		xs[i] = byte(sh.index)
+		ys[i] = sh.value
+	}
+
+	// every extra share must lie on the same polynomials
+	for _, sh := range unique[first.threshold:] {
+		want, err := internal.InterpolateShares(xs, ys, byte(sh.index))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrShare, err)
+		}
+		ok := subtle.ConstantTimeCompare(want, sh.value) == 1
+		internal.Wipe(want)
+		if !ok {
+			return nil, fmt.Errorf("%w: share %d does not match the others", ErrShare, sh.index)
+		}
+	}
+
+	secret, err := internal.InterpolateShares(xs, ys, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrShare, err)
+	}
+
+	return &ShareIdentity{
+		id:        first.id,
+		threshold: first.threshold,
+		total:     first.total,
+		secret:    secret,
+	}, nil
+}
+
+func (i *ShareIdentity) unwrap(st internal.Stanza) ([]byte, error) {
+	if st.Type != internal.StanzaShares {
+		return nil, errWrongIdentity
+	}
+	if len(st.Body) != splitIDSize+2+16+fileKeySize+internal.GCMTagSize {
+		return nil, fmt.Errorf("%w: invalid shares stanza length %d", ErrInvalidHeader, len(st.Body))
+	}
+	if !bytes.Equal(st.Body[:splitIDSize], i.id[:]) {
+		return nil, errWrongIdentity
+	}
+
+	threshold, total := int(st.Body[splitIDSize]), int(st.Body[splitIDSize+1])
+	if threshold != i.threshold || total != i.total {
+		return nil, fmt.Errorf("%w: shares are %d of %d, the file was split %d of %d", ErrShare, i.threshold, i.total, threshold, total)
+	}
+
+	salt := st.Body[splitIDSize+2 : splitIDSize+2+16]
+	fileKey, err := openFileKey(i.secret, shareSalt(i.id, salt), sharesInfo, st.Body[splitIDSize+2+16:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: the shares do not recover the file key, one of them is wrong", ErrShare)
+	}
+	return fileKey, nil
+}
+
+// shareSalt binds a wrapping key to the split and to one stanza.
+func shareSalt(id [splitIDSize]byte, salt []byte) []byte {
+	return append(id[:splitIDSize:splitIDSize], salt...)
+}
@@ -0,0 +1,275 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+const (
+	signingKeyHRP = "GENC-SIGNING-KEY-"
+	verifyKeyHRP  = "gencsign"
+	signatureHRP  = "gencsig"
+
+	// SignatureExt is appended to the name of a file to find its signature.
+	SignatureExt = ".sig"
+
+	// binds signatures to signing encrypted files with go-enc
+	signatureContext = "go-enc/signature"
+)
+
+// SigningKey is an Ed25519 private key files are signed with, written as
+// GENC-SIGNING-KEY-1...
+type SigningKey struct {
+	priv ed25519.PrivateKey
+}
+
+// VerifyKey is the public key matching a SigningKey, written as gencsign1...
+type VerifyKey struct {
+	pub ed25519.PublicKey
+}
+
+// GenerateSigningKey creates a new random signing key.
+func GenerateSigningKey() (*SigningKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{priv: priv}, nil
+}
+
+// ParseSigningKey parses a GENC-SIGNING-KEY-1... key.
+func ParseSigningKey(s string) (*SigningKey, error) {
+	hrp, seed, err := internal.Bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSigningKey, err)
+	}
+	defer internal.Wipe(seed)
+
+	if hrp != strings.ToLower(signingKeyHRP) || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%w: not a signing key", ErrSigningKey)
+	}
+	return &SigningKey{priv: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// ParseVerifyKey parses a gencsign1... public key.
+func ParseVerifyKey(s string) (*VerifyKey, error) {
+	hrp, pub, err := internal.Bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSigningKey, err)
+	}
+	if hrp != verifyKeyHRP || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: not a signing public key", ErrSigningKey)
+	}
+	return &VerifyKey{pub: pub}, nil
+}
+
+// ReadSigningKey reads the signing key from a key file written by keygen
+// --sign, blank lines and lines starting with # are ignored.
+func ReadSigningKey(r io.Reader) (*SigningKey, error) {
+	lines, err := keyLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) != 1 {
+		return nil, fmt.Errorf("%w: expected one signing key, found %d lines", ErrSigningKey, len(lines))
+	}
+
+	// errors never repeat the line, it is a secret
+	key, err := ParseSigningKey(lines[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signing key", ErrSigningKey)
+	}
+	return key, nil
+}
+
+// ParseVerifyKeys reads trusted public keys, one per line, blank lines and
+// lines starting with # are ignored.
+func ParseVerifyKeys(r io.Reader) ([]*VerifyKey, error) {
+	lines, err := keyLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*VerifyKey
+	for _, line := range lines {
+		key, err := ParseVerifyKey(line)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no public keys found", ErrSigningKey)
+	}
+	return keys, nil
+}
+
+// keyLines returns the lines of r that are neither blank nor comments.
+func keyLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// Public returns the public key matching k.
+func (k *SigningKey) Public() *VerifyKey {
+	return &VerifyKey{pub: k.priv.Public().(ed25519.PublicKey)}
+}
+
+func (k *SigningKey) String() string {
+	s, _ := internal.Bech32Encode(signingKeyHRP, k.priv.Seed())
+	return s
+}
+
+func (k *VerifyKey) String() string {
+	s, _ := internal.Bech32Encode(verifyKeyHRP, k.pub)
+	return s
+}
+
+// Sign signs everything read from r and returns the signature file content.
+func Sign(key *SigningKey, r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	return key.sign(h)
+}
+
+// sign signs the sha512 of a file with Ed25519ph, which unlike plain Ed25519
+// does not need the whole file in memory.
+func (k *SigningKey) sign(h hash.Hash) ([]byte, error) {
+	sig, err := k.priv.Sign(nil, h.Sum(nil), &ed25519.Options{Hash: crypto.SHA512, Context: signatureContext})
+	if err != nil {
+		return nil, err
+	}
+
+	pub := k.Public()
+	s, err := internal.Bech32Encode(signatureHRP, append(bytes.Clone(pub.pub), sig...))
+	if err != nil {
+		return nil, err
+	}
+	return fmt.Appendf(nil, "# signed by %s\n%s\n", pub, s), nil
+}
+
+// Verify checks that sig, the content of a signature file, is a signature
+// over everything read from r by one of trusted, and returns that key.
+func Verify(r io.Reader, sig []byte, trusted []*VerifyKey) (*VerifyKey, error) {
+	s, err := parseSignature(sig, trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadChunk, err)
+	}
+
+	if err := s.verify(h); err != nil {
+		return nil, err
+	}
+	return s.key, nil
+}
+
+// signature is a parsed signature file by a trusted key.
+type signature struct {
+	key *VerifyKey
+	sig []byte
+}
+
+// parseSignature parses sig, the content of a signature file, and checks
+// that it was made by one of trusted.
+func parseSignature(sig []byte, trusted []*VerifyKey) (*signature, error) {
+	lines, err := keyLines(bytes.NewReader(sig))
+	if err != nil || len(lines) != 1 {
+		return nil, fmt.Errorf("%w: malformed signature file", ErrSignature)
+	}
+
+	hrp, data, err := internal.Bech32Decode(lines[0])
+	if err != nil || hrp != signatureHRP || len(data) != ed25519.PublicKeySize+ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: malformed signature", ErrSignature)
+	}
+	signer := &VerifyKey{pub: data[:ed25519.PublicKeySize]}
+
+	for _, k := range trusted {
+		if k.pub.Equal(signer.pub) {
+			return &signature{key: k, sig: data[ed25519.PublicKeySize:]}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: signed by %s, which is not trusted", ErrSignature, signer)
+}
+
+// verify checks the signature against the sha512 of a file's content.
+func (s *signature) verify(h hash.Hash) error {
+	opts := &ed25519.Options{Hash: crypto.SHA512, Context: signatureContext}
+	if err := ed25519.VerifyWithOptions(s.key.pub, h.Sum(nil), s.sig, opts); err != nil {
+		return fmt.Errorf("%w: the file does not match its signature by %s", ErrSignature, s.key)
+	}
+	return nil
+}
+
+// ReadSignature reads the signature file of the file at path.
+func ReadSignature(path string) ([]byte, error) {
+	sig, err := os.ReadFile(path + SignatureExt)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s is not signed, %s%s does not exist", ErrSignature, path, path, SignatureExt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	return sig, nil
+}
+
+// VerifyFile checks f, which was opened from path, against the signature
+// beside path and rewinds it.
+func VerifyFile(f *os.File, path string, trusted []*VerifyKey) (*VerifyKey, error) {
+	sig, err := ReadSignature(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := Verify(f, sig, trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrOpenFile, err)
+	}
+	return key, nil
+}
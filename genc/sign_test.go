@@ -0,0 +1,146 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// signedFile encrypts plaintext and signs the ciphertext with key.
+func signedFile(t *testing.T, key *SigningKey, plaintext []byte) (data, sig []byte) {
+	t.Helper()
+	data = fuzzStanzaFile(t, plaintext, 1)
+	sig, err := Sign(key, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, sig
+}
+
+func TestDecryptStreamVerifiesWhatItDecrypts(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("KEY=value\n")
+	data, sig := signedFile(t, key, plaintext)
+	swapped, _ := signedFile(t, key, []byte("KEY=evil\n"))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		sig     []byte
+		trusted []*VerifyKey
+		wantErr error
+	}{
+		{name: "signed", data: data, sig: sig, trusted: []*VerifyKey{key.Public()}},
+		{name: "swapped file", data: swapped, sig: sig, trusted: []*VerifyKey{key.Public()}, wantErr: ErrSignature},
+		{name: "untrusted", data: data, sig: sig, trusted: []*VerifyKey{other.Public()}, wantErr: ErrSignature},
+		{name: "unsigned", data: data, trusted: []*VerifyKey{key.Public()}, wantErr: ErrSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a plain writer, held back without Streaming too
+			var out bytes.Buffer
+			err := DecryptStream(fuzzPass, bytes.NewReader(tt.data), &out, Options{VerifyWith: tt.trusted, Signature: tt.sig})
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("DecryptStream: %v", err)
+				}
+				if !bytes.Equal(out.Bytes(), plaintext) {
+					t.Fatalf("got %q, want %q", out.Bytes(), plaintext)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// nothing is released before the signature is checked
+			if out.Len() != 0 {
+				t.Fatalf("%d bytes written before the signature was checked", out.Len())
+			}
+		})
+	}
+}
+
+func TestDecryptLeavesNoOutputOnBadSignature(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sig := signedFile(t, key, []byte("KEY=value\n"))
+	swapped, _ := signedFile(t, key, []byte("KEY=evil\n"))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.genc")
+	if err := os.WriteFile(path, swapped, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+SignatureExt, sig, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "secrets")
+	err = Decrypt(fuzzPass, path, Options{Output: output, VerifyWith: []*VerifyKey{key.Public()}})
+	if !errors.Is(err, ErrSignature) {
+		t.Fatalf("got %v, want %v", err, ErrSignature)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("output exists after a failed signature check: %v", err)
+	}
+}
+
+func TestEncryptKeepsFileWhenSignatureFails(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets")
+	if err := os.WriteFile(path, []byte("KEY=value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// a directory in the way of the signature file
+	if err := os.Mkdir(path+".genc"+SignatureExt, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Encrypt(nil, path, Options{SignWith: key, Recipients: []Recipient{id.Recipient()}})
+	if !errors.Is(err, ErrSign) {
+		t.Fatalf("got %v, want %v", err, ErrSign)
+	}
+	if _, err := os.Stat(path + ".genc"); err != nil {
+		t.Fatalf("encrypted file was not kept: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// checkSpace fails with ErrNoSpace when the filesystem that will hold path
+// has fewer than need bytes available. Filesystems that cannot tell are not
+// checked.
+func checkSpace(path string, need int64) error {
+	dir := filepath.Dir(path)
+
+	free, ok := internal.FreeSpace(dir)
+	if !ok || uint64(need) <= free {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s needs about %s, %s has %s available",
+		ErrNoSpace, path, internal.FormatSize(need), dir, internal.FormatSize(int64(free)))
+}
+
+// encryptedSize estimates the output of Encrypt with header for the input
+// described by info. The overhead per chunk is fixed, so this is exact unless
+// the header is sparse, when holes are taken to be the only zero chunks.
+func encryptedSize(header internal.HeaderV2, info fs.FileInfo, md *Metadata) int64 {
+	plain := info.Size()
+	if header.Sparse {
+		plain = internal.AllocatedSize(info)
+	}
+
+	metadata := -1
+	if md != nil {
+		if data, err := json.Marshal(md); err == nil {
+			metadata = len(data)
+		}
+	}
+
+	header.Metadata = md != nil
+
+	return internal.EncryptedSize(header, plain, metadata)
+}
@@ -0,0 +1,141 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+func TestEncryptedSize(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := &Metadata{Mode: 0o600, Mtime: time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)}
+
+	tests := []struct {
+		name string
+		size int
+		md   *Metadata
+	}{
+		{name: "empty", size: 0},
+		{name: "one byte", size: 1},
+		{name: "just under a chunk", size: internal.ChunkSize - 1},
+		{name: "one chunk", size: internal.ChunkSize},
+		{name: "just over a chunk", size: internal.ChunkSize + 1},
+		{name: "with metadata", size: 2*internal.ChunkSize + 5, md: md},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte{'x'}, tc.size)
+			path := filepath.Join(t.TempDir(), "secrets")
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fw, err := Options{Recipients: []Recipient{id.Recipient()}}.newWriter(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := fw.write(bytes.NewReader(data), &out, tc.md); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := fw.size(info, tc.md), int64(out.Len()); got != want {
+				t.Errorf("estimated %d bytes, wrote %d", got, want)
+			}
+		})
+	}
+}
+
+func TestEncryptSparse(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "disk.img")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one byte of data in the third chunk, holes around it
+	const size = 4 * internal.ChunkSize
+	if _, err := f.WriteAt([]byte{1}, 2*internal.ChunkSize+10); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := Encrypt(nil, path, Options{Recipients: []Recipient{id.Recipient()}, Sparse: true}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path + ".genc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 2*internal.ChunkSize {
+		t.Errorf("encrypted to %d bytes, zero chunks were not stored as their length", info.Size())
+	}
+
+	out := filepath.Join(dir, "restored.img")
+	if err := Decrypt(nil, path+".genc", Options{Identities: []Identity{id}, Output: out}); err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("restored %d bytes, want %d: %v", len(got), len(want), err)
+	}
+	if info, err := os.Stat(out); err != nil || internal.AllocatedSize(info) >= size {
+		t.Errorf("restored file is not sparse: %v", err)
+	}
+}
+
+func TestCheckSpace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.genc")
+	if _, ok := internal.FreeSpace(filepath.Dir(path)); !ok {
+		t.Skip("free space is not known here")
+	}
+
+	if err := checkSpace(path, 1); err != nil {
+		t.Errorf("one byte: %v", err)
+	}
+	if err := checkSpace(path, 1<<62); !errors.Is(err, ErrNoSpace) {
+		t.Errorf("got %v, want %v", err, ErrNoSpace)
+	}
+}
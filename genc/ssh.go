@@ -0,0 +1,337 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/irrisdev/go-enc/internal"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// binds wrapping keys to this use of converted ssh-ed25519 keys
+	sshEd25519Info = "go-enc/ssh-ed25519"
+	// OAEP label of ssh-rsa stanzas
+	sshRSALabel = "go-enc/ssh-rsa"
+
+	// stanzas start with a short hash of the ssh public key, so identities
+	// skip the stanzas of other keys without asking for a passphrase
+	sshTagSize = 4
+
+	minRSABits = 2048
+)
+
+// SSHRecipient is an ssh-ed25519 or ssh-rsa public key, as found in
+// authorized_keys. Ed25519 keys are converted to X25519, RSA keys wrap the
+// file key with RSA-OAEP.
+type SSHRecipient struct {
+	key    ssh.PublicKey
+	x25519 *ecdh.PublicKey
+	rsa    *rsa.PublicKey
+}
+
+// SSHIdentity is an OpenSSH private key. An encrypted key is only decrypted,
+// with the passphrase callback, once a stanza for it is found.
+type SSHIdentity struct {
+	pub        ssh.PublicKey
+	pem        []byte
+	passphrase func() ([]byte, error)
+
+	once sync.Once
+	priv any
+	err  error
+}
+
+// ParseSSHRecipient parses an authorized_keys line holding an ssh-ed25519 or
+// ssh-rsa key.
+func ParseSSHRecipient(s string) (*SSHRecipient, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRecipient, err)
+	}
+	return newSSHRecipient(key)
+}
+
+func newSSHRecipient(key ssh.PublicKey) (*SSHRecipient, error) {
+	ck, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported ssh key type %s", ErrRecipient, key.Type())
+	}
+
+	r := &SSHRecipient{key: key}
+	switch pub := ck.CryptoPublicKey().(type) {
+	case ed25519.PublicKey:
+		u, err := ed25519ToX25519(pub)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRecipient, err)
+		}
+		if r.x25519, err = ecdh.X25519().NewPublicKey(u); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRecipient, err)
+		}
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: %d bit ssh-rsa key is too small, need %d", ErrRecipient, pub.N.BitLen(), minRSABits)
+		}
+		r.rsa = pub
+	default:
+		return nil, fmt.Errorf("%w: unsupported ssh key type %s", ErrRecipient, key.Type())
+	}
+	return r, nil
+}
+
+// IsSSHPrivateKey reports whether data looks like a PEM encoded private key
+// rather than an identity file.
+func IsSSHPrivateKey(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return bytes.HasPrefix(data, []byte("-----BEGIN ")) && bytes.Contains(data, []byte("PRIVATE KEY-----"))
+}
+
+// ParseSSHIdentity parses an ssh-ed25519 or ssh-rsa private key. passphrase
+// is called for encrypted keys, at most once, and may be nil when the key is
+// not encrypted.
+func ParseSSHIdentity(pemBytes []byte, passphrase func() ([]byte, error)) (*SSHIdentity, error) {
+	id := &SSHIdentity{pem: bytes.Clone(pemBytes), passphrase: passphrase}
+
+	priv, err := ssh.ParseRawPrivateKey(pemBytes)
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing) && missing.PublicKey != nil:
+		// openssh keys keep their public key in the clear
+		id.pub = missing.PublicKey
+	case errors.As(err, &missing):
+		// older pem keys do not, decrypt now to learn it
+		if priv, err = id.decrypt(); err != nil {
+			return nil, err
+		}
+		id.once.Do(func() { id.priv = priv })
+	case err != nil:
+		return nil, fmt.Errorf("%w: %w", ErrIdentity, err)
+	default:
+		id.once.Do(func() { id.priv = priv })
+	}
+
+	if id.pub == nil {
+		signer, err := ssh.NewSignerFromKey(priv)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrIdentity, err)
+		}
+		id.pub = signer.PublicKey()
+	}
+
+	if _, err := newSSHRecipient(id.pub); err != nil {
+		return nil, fmt.Errorf("%w: unsupported ssh key type %s", ErrIdentity, id.pub.Type())
+	}
+	return id, nil
+}
+
+// Recipient returns the public key matching i.
+func (i *SSHIdentity) Recipient() *SSHRecipient {
+	r, _ := newSSHRecipient(i.pub)
+	return r
+}
+
+// AuthorizedKey returns r in authorized_keys form, as ParseSSHRecipient
+// takes it.
+func (r *SSHRecipient) AuthorizedKey() string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(r.key)))
+}
+
+// decrypt decrypts the private key with the passphrase callback.
+func (i *SSHIdentity) decrypt() (any, error) {
+	if i.passphrase == nil {
+		return nil, fmt.Errorf("%w: ssh key is encrypted and no passphrase can be asked for", ErrIdentity)
+	}
+	pass, err := i.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	defer internal.Wipe(pass)
+
+	priv, err := ssh.ParseRawPrivateKeyWithPassphrase(i.pem, pass)
+	if errors.Is(err, x509.IncorrectPasswordError) {
+		return nil, fmt.Errorf("%w: wrong passphrase for the ssh key", ErrAuth)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIdentity, err)
+	}
+	return priv, nil
+}
+
+// privateKey returns the private key, decrypting it on first use.
+func (i *SSHIdentity) privateKey() (any, error) {
+	i.once.Do(func() {
+		i.priv, i.err = i.decrypt()
+	})
+	return i.priv, i.err
+}
+
+func (r *SSHRecipient) String() string {
+	return r.key.Type() + " " + ssh.FingerprintSHA256(r.key)
+}
+
+func (r *SSHRecipient) wrap(fileKey []byte) (internal.Stanza, error) {
+	body := sshTag(r.key)
+
+	if r.rsa != nil {
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, r.rsa, fileKey, []byte(sshRSALabel))
+		if err != nil {
+			return internal.Stanza{}, err
+		}
+		return internal.Stanza{Type: internal.StanzaSSHRSA, Body: append(body, wrapped...)}, nil
+	}
+
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+
+	shared, err := eph.ECDH(r.x25519)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+	defer internal.Wipe(shared)
+
+	ephPub := eph.PublicKey().Bytes()
+
+	wrapped, err := sealFileKey(shared, x25519Salt(ephPub, r.x25519), sshEd25519Info, fileKey)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+
+	body = append(body, ephPub...)
+	return internal.Stanza{Type: internal.StanzaSSHEd25519, Body: append(body, wrapped...)}, nil
+}
+
+func (i *SSHIdentity) unwrap(st internal.Stanza) ([]byte, error) {
+	switch {
+	case st.Type == internal.StanzaSSHEd25519 && i.pub.Type() == ssh.KeyAlgoED25519:
+		if len(st.Body) != sshTagSize+32+fileKeySize+internal.GCMTagSize {
+			return nil, fmt.Errorf("%w: invalid ssh-ed25519 stanza length %d", ErrInvalidHeader, len(st.Body))
+		}
+	case st.Type == internal.StanzaSSHRSA && i.pub.Type() == ssh.KeyAlgoRSA:
+		if len(st.Body) <= sshTagSize {
+			return nil, fmt.Errorf("%w: invalid ssh-rsa stanza length %d", ErrInvalidHeader, len(st.Body))
+		}
+	default:
+		return nil, errWrongIdentity
+	}
+
+	if !bytes.Equal(st.Body[:sshTagSize], sshTag(i.pub)) {
+		return nil, errWrongIdentity
+	}
+
+	priv, err := i.privateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#8 keys parse to the value, OpenSSH keys to a pointer
+	if k, ok := priv.(ed25519.PrivateKey); ok {
+		priv = &k
+	}
+
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		fileKey, err := rsa.DecryptOAEP(sha256.New(), nil, k, st.Body[sshTagSize:], []byte(sshRSALabel))
+		if err != nil {
+			return nil, errWrongIdentity
+		}
+		return fileKey, nil
+
+	case *ed25519.PrivateKey:
+		// the X25519 scalar is the hashed seed, as ed25519 derives its own
+		h := sha512.Sum512(k.Seed())
+		defer internal.Wipe(h[:])
+
+		x, err := ecdh.X25519().NewPrivateKey(h[:32])
+		if err != nil {
+			return nil, err
+		}
+
+		ephPub := st.Body[sshTagSize : sshTagSize+32]
+		eph, err := ecdh.X25519().NewPublicKey(ephPub)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+
+		shared, err := x.ECDH(eph)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+		defer internal.Wipe(shared)
+
+		return openFileKey(shared, x25519Salt(ephPub, x.PublicKey()), sshEd25519Info, st.Body[sshTagSize+32:])
+	}
+
+	return nil, fmt.Errorf("%w: unsupported ssh private key type %T", ErrIdentity, priv)
+}
+
+// sshTag is the start of the sha256 of the wire form of key.
+func sshTag(key ssh.PublicKey) []byte {
+	sum := sha256.Sum256(key.Marshal())
+	return sum[:sshTagSize]
+}
+
+// curve25519P is 2^255 - 19.
+var curve25519P, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+// ed25519ToX25519 maps an Edwards y coordinate to the Montgomery u = (1+y)/(1-y)
+// of the birationally equivalent X25519 point.
+func ed25519ToX25519(pub ed25519.PublicKey) ([]byte, error) {
+	// little endian y with the sign of x in the top bit
+	be := make([]byte, len(pub))
+	for i, b := range pub {
+		be[len(pub)-1-i] = b
+	}
+	be[0] &= 0x7f
+
+	y := new(big.Int).SetBytes(be)
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+
+	one := big.NewInt(1)
+	den := new(big.Int).Sub(one, y)
+	den.Mod(den, curve25519P)
+	if den.Sign() == 0 {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+
+	u := new(big.Int).Add(one, y)
+	u.Mul(u, den.ModInverse(den, curve25519P))
+	u.Mod(u, curve25519P)
+
+	out := make([]byte, 32)
+	u.FillBytes(out)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
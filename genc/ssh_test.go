@@ -0,0 +1,209 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHIdentityKeyForms(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	openssh := func(key crypto.PrivateKey) []byte {
+		block, err := ssh.MarshalPrivateKey(key, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(block)
+	}
+	pkcs8 := func(key crypto.PrivateKey) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	tests := []struct {
+		name string
+		pem  []byte
+	}{
+		{name: "ed25519 openssh", pem: openssh(edKey)},
+		{name: "ed25519 pkcs8", pem: pkcs8(edKey)},
+		{name: "rsa openssh", pem: openssh(rsaKey)},
+		{name: "rsa pkcs8", pem: pkcs8(rsaKey)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ParseSSHIdentity(tt.pem, nil)
+			if err != nil {
+				t.Fatalf("ParseSSHIdentity: %v", err)
+			}
+
+			fileKey := make([]byte, fileKeySize)
+			rand.Read(fileKey)
+			st, err := id.Recipient().wrap(fileKey)
+			if err != nil {
+				t.Fatalf("wrap: %v", err)
+			}
+
+			got, err := id.unwrap(st)
+			if err != nil {
+				t.Fatalf("unwrap: %v", err)
+			}
+			if !bytes.Equal(got, fileKey) {
+				t.Fatal("unwrapped a different file key")
+			}
+		})
+	}
+}
+
+func TestSSHEncryptedKeys(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := func(key crypto.PrivateKey) []byte {
+		block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte("key passphrase"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pem.EncodeToMemory(block)
+	}
+	recipient := func(key crypto.Signer) Recipient {
+		pub, err := ssh.NewPublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// as a line of authorized_keys, comment and all
+		r, err := ParseRecipient(strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))) + " alice@laptop")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		pem     []byte
+		to      Recipient
+		pass    string
+		noPass  bool
+		err     error
+		prompts int
+	}{
+		{name: "ed25519", pem: encrypted(edKey), to: recipient(edKey), pass: "key passphrase", prompts: 1},
+		{name: "rsa", pem: encrypted(rsaKey), to: recipient(rsaKey), pass: "key passphrase", prompts: 1},
+		{name: "ed25519 wrong passphrase", pem: encrypted(edKey), to: recipient(edKey), pass: "wrong", err: ErrAuth, prompts: 1},
+		{name: "rsa wrong passphrase", pem: encrypted(rsaKey), to: recipient(rsaKey), pass: "wrong", err: ErrAuth, prompts: 1},
+		{name: "no way to ask", pem: encrypted(edKey), to: recipient(edKey), noPass: true, err: ErrIdentity},
+		// a stanza for another key is skipped without asking
+		{name: "other ed25519 key", pem: encrypted(edKey), to: recipient(otherKey), err: ErrNoIdentity},
+		{name: "rsa key for ed25519 file", pem: encrypted(rsaKey), to: recipient(edKey), err: ErrNoIdentity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompts := 0
+			passphrase := func() ([]byte, error) {
+				prompts++
+				return []byte(tt.pass), nil
+			}
+			if tt.noPass {
+				passphrase = nil
+			}
+
+			// the public key of an encrypted openssh key is read without asking
+			id, err := ParseSSHIdentity(tt.pem, passphrase)
+			if err != nil {
+				t.Fatalf("ParseSSHIdentity: %v", err)
+			}
+			if prompts != 0 {
+				t.Fatal("asked for the passphrase while parsing")
+			}
+
+			var enc bytes.Buffer
+			if err := EncryptStream(nil, strings.NewReader("KEY=value\n"), &enc, Options{Recipients: []Recipient{tt.to}}); err != nil {
+				t.Fatal(err)
+			}
+
+			// twice, the passphrase is asked for once
+			for range 2 {
+				var out bytes.Buffer
+				err := DecryptStream(nil, bytes.NewReader(enc.Bytes()), &out, Options{Identities: []Identity{id}})
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+				if err == nil && out.String() != "KEY=value\n" {
+					t.Fatalf("decrypted %q", out.String())
+				}
+			}
+			if prompts != tt.prompts {
+				t.Errorf("asked for the passphrase %d times, want %d", prompts, tt.prompts)
+			}
+		})
+	}
+}
+
+func TestSSHRecipientRefused(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(&small.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []string{
+		strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+		"ssh-ed25519 AAAAnotbase64",
+		"ssh-ed25519",
+	} {
+		if _, err := ParseRecipient(s); !errors.Is(err, ErrRecipient) {
+			t.Errorf("%.40s: got %v, want %v", s, err, ErrRecipient)
+		}
+	}
+}
@@ -0,0 +1,756 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// Metadata is the file mode, ownership, timestamps and user extended
+// attributes stored encrypted inside a .genc file.
+type Metadata = internal.Metadata
+
+// fileHeader is a decoded header of either format version.
+type fileHeader struct {
+	version  int
+	salt     []byte
+	kdf      internal.KDFParams
+	metadata bool
+	passForm PassphraseForm
+	sparse   bool
+	stanzas  []internal.Stanza
+	keyfile  bool
+	sum      [sha256.Size]byte
+}
+
+// EncryptStream reads plaintext from r and writes a genc container to w.
+// opts.Metadata, when set, is stored encrypted alongside the content, with
+// opts.Sparse runs of zero chunks are stored as their length.
+// Cancelling opts.Context leaves w holding an incomplete container.
+func EncryptStream(pass []byte, r io.Reader, w io.Writer, opts ...Options) error {
+	o := getOptions(opts)
+
+	fw, err := o.newWriter(pass)
+	if err != nil {
+		return err
+	}
+	defer internal.ProtectKey(fw.key())()
+
+	return fw.write(o.reader(r), w, o.Metadata)
+}
+
+// fileWriter writes new files of one format with a key made up front, so
+// the output size is known and the result can be read back with the key.
+type fileWriter interface {
+	key() []byte
+	size(info fs.FileInfo, md *Metadata) int64
+	write(r io.Reader, w io.Writer, md *Metadata) error
+	verify(reader *bufio.Reader, w io.Writer) error
+}
+
+// newWriter makes a new key for o.Format.
+func (o Options) newWriter(pass []byte) (fileWriter, error) {
+	if o.Format == FormatAge {
+		if o.Sparse {
+			return nil, fmt.Errorf("%w: age files cannot store sparse chunks", ErrFormat)
+		}
+		if len(o.Keyfile) > 0 {
+			return nil, fmt.Errorf("%w: age files cannot use a keyfile", ErrFormat)
+		}
+		return newAgeWriter(pass, o.Recipients)
+	}
+
+	hash, header, err := o.newKey(pass)
+	if err != nil {
+		return nil, err
+	}
+	header.Sparse = o.Sparse
+
+	return &gencWriter{hash: hash, header: header}, nil
+}
+
+// gencWriter writes version 2 genc files.
+type gencWriter struct {
+	hash   []byte
+	header internal.HeaderV2
+}
+
+func (g *gencWriter) key() []byte {
+	return g.hash
+}
+
+func (g *gencWriter) size(info fs.FileInfo, md *Metadata) int64 {
+	return encryptedSize(g.header, info, md)
+}
+
+func (g *gencWriter) write(r io.Reader, w io.Writer, md *Metadata) error {
+	return encryptStream(g.hash, g.header, md, r, w)
+}
+
+func (g *gencWriter) verify(reader *bufio.Reader, w io.Writer) error {
+	header, err := readHeader(reader, 0)
+	if err != nil {
+		return err
+	}
+	return decryptChunks(fixedKey(g.hash), header, reader, w, nil)
+}
+
+// newKey returns a new file key and the header describing how to recover it:
+// wrapped for o.Recipients, and for pass as well when it is set, or else
+// derived from pass and o.Keyfile.
+func (o Options) newKey(pass []byte) ([]byte, internal.HeaderV2, error) {
+	if len(o.Recipients) > 0 {
+		if len(o.Keyfile) > 0 {
+			return nil, internal.HeaderV2{}, fmt.Errorf("%w: a keyfile only works with passphrase files", ErrKeyfile)
+		}
+		recipients := o.Recipients
+		if len(pass) > 0 {
+			recipients = append(recipients[:len(recipients):len(recipients)], NewPassphraseRecipient(pass))
+		}
+		return newRecipientKey(recipients)
+	}
+	return newPassphraseKey(pass, o.Keyfile)
+}
+
+// newRecipientKey generates a random file key and wraps it for every recipient.
+func newRecipientKey(recipients []Recipient) ([]byte, internal.HeaderV2, error) {
+	var header internal.HeaderV2
+
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, header, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+
+	for _, r := range recipients {
+		st, err := r.wrap(fileKey)
+		if err != nil {
+			internal.Wipe(fileKey)
+			return nil, header, fmt.Errorf("%w %s: %w", ErrRecipient, r, err)
+		}
+		header.Stanzas = append(header.Stanzas, st)
+	}
+
+	if n := len(internal.EncodeHeaderV2(header)); n > internal.HeaderV2PrefixSize+math.MaxUint16 {
+		internal.Wipe(fileKey)
+		return nil, header, fmt.Errorf("%w: too many recipients for the header", ErrRecipient)
+	}
+
+	return fileKey, header, nil
+}
+
+// newPassphraseKey derives a key from the NFC form of pass, and keyfile when
+// set, using a fresh random salt and returns it with the header describing
+// how it was derived.
+func newPassphraseKey(pass, keyfile []byte) ([]byte, internal.HeaderV2, error) {
+	var header internal.HeaderV2
+
+	// generate salt
+	salt, err := internal.GenerateSalt16()
+	if err != nil {
+		return nil, header, fmt.Errorf("%w: %w", ErrNewSalt, err)
+	}
+
+	copy(header.Salt[:], salt)
+	header.KDF = internal.DefaultKDFParams()
+	header.PassForm = internal.PassNFC
+
+	// the same text typed on any platform must give the same key
+	normalized := internal.NormalizePass(pass, header.PassForm)
+	defer internal.Wipe(normalized)
+
+	// generate hash using argon2id
+	hash := internal.DeriveKey(normalized, salt, header.KDF)
+
+	if len(keyfile) > 0 {
+		header.Keyfile = true
+		mixed := internal.MixKeyfile(hash, keyfile, salt)
+		internal.Wipe(hash)
+		hash = mixed
+	}
+
+	return hash, header, nil
+}
+
+// encryptStream writes header followed by md and r encrypted with hash.
+func encryptStream(hash []byte, header internal.HeaderV2, md *Metadata, r io.Reader, w io.Writer) error {
+	gcm, err := newGCM(hash)
+	if err != nil {
+		return err
+	}
+
+	// create bufio RW buffers limited to RWSize
+	reader := bufio.NewReaderSize(r, internal.RWSize)
+	writer := bufio.NewWriterSize(w, internal.RWSize)
+
+	// encode and write file header
+	header.Metadata = md != nil
+	raw := internal.EncodeHeaderV2(header)
+
+	if _, err := writer.Write(raw); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteHeader, err)
+	}
+
+	cw := &chunkWriter{gcm: gcm, w: writer, sum: sha256.Sum256(raw)}
+
+	// metadata travels in the first chunk
+	if md != nil {
+		data, err := json.Marshal(md)
+		if err != nil {
+			return err
+		}
+		// decoders refuse chunks larger than this
+		if len(data) > internal.ChunkSize {
+			return fmt.Errorf("%w: metadata is %d bytes, more than %d", ErrMetadata, len(data), internal.ChunkSize)
+		}
+		if err := cw.seal(data, false); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, internal.ChunkSize) // 1MiB
+
+	// zero chunks not yet written, sparse files store them as one run
+	var zeros uint64
+
+	for {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: %w", ErrReadChunk, err)
+		}
+
+		// a short read ends the stream, otherwise look ahead for EOF so the
+		// last chunk can be marked, an empty input still gets a final chunk
+		final := err != nil
+		if !final {
+			if _, err := reader.Peek(1); err == io.EOF {
+				final = true
+			}
+		}
+
+		if header.Sparse && n > 0 && isZero(buf[:n]) {
+			zeros += uint64(n)
+			if final {
+				if err := cw.sealZeros(zeros, true); err != nil {
+					return err
+				}
+				break
+			}
+			continue
+		}
+
+		if zeros > 0 {
+			if err := cw.sealZeros(zeros, false); err != nil {
+				return err
+			}
+			zeros = 0
+		}
+
+		if err := cw.seal(buf[:n], final); err != nil {
+			return err
+		}
+
+		if final {
+			break
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+
+	return nil
+}
+
+// chunkWriter seals version 2 chunks bound to their header and position.
+type chunkWriter struct {
+	gcm   cipher.AEAD
+	w     io.Writer
+	sum   [sha256.Size]byte
+	index uint64
+}
+
+func (c *chunkWriter) seal(plaintext []byte, final bool) error {
+	return c.sealChunk(plaintext, final, false)
+}
+
+// sealZeros writes a run of n zero bytes as a single chunk.
+func (c *chunkWriter) sealZeros(n uint64, final bool) error {
+	return c.sealChunk(binary.BigEndian.AppendUint64(nil, n), final, true)
+}
+
+func (c *chunkWriter) sealChunk(plaintext []byte, final, zeros bool) error {
+
+	// generate random nonce
+	nonce := make([]byte, c.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("%w: %w", ErrNewNonce, err)
+	}
+
+	ciphertext := c.gcm.Seal(nil, nonce, plaintext, internal.ChunkAAD(c.sum, c.index, final, zeros))
+	c.index++
+
+	// encode the chunk header
+	length, err := internal.JoinChunkLength(len(ciphertext), final, zeros)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrChunkTooLarge, err)
+	}
+
+	header := internal.EncodeChunkHeader(length, nonce)
+
+	// write header first then ciphertext
+	if _, err := c.w.Write(header); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	if _, err := c.w.Write(ciphertext); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+
+	return nil
+}
+
+// DecryptStream reads a genc container from r and writes the plaintext to w.
+// When opts.Metadata is set, stored metadata is decoded into it. Files written
+// before passphrases were normalised are also tried with the NFC and NFD forms
+// of pass, opts.PassphraseMatch receives the form that worked. age files,
+// OpenPGP messages from gpg --symmetric and openssl enc files are recognised
+// by how they start, the latter two only decrypt with pass as typed. With
+// opts.VerifyWith the input must match opts.Signature, which is checked over
+// the same read the plaintext comes from.
+func DecryptStream(pass []byte, r io.Reader, w io.Writer, opts ...Options) error {
+	o := getOptions(opts)
+	if len(o.VerifyWith) == 0 {
+		return o.decryptStream(pass, r, w)
+	}
+
+	// an unsigned file or an untrusted signer is refused before decrypting
+	if o.Signature == nil {
+		return fmt.Errorf("%w: no signature to verify the file with", ErrSignature)
+	}
+	sig, err := parseSignature(o.Signature, o.VerifyWith)
+	if err != nil {
+		return err
+	}
+
+	// the plaintext is only released once the signature is checked, Decrypt
+	// simply doesn't commit its output on error
+	dst := w
+	var held *heldWriter
+	if !discards(w) {
+		held = &heldWriter{}
+		defer held.wipe()
+		dst = held
+	}
+
+	h := sha512.New()
+	if err := o.decryptStream(pass, io.TeeReader(r, h), dst); err != nil {
+		if errors.Is(err, errHeldBuffer) {
+			return fmt.Errorf("%w: signed file is larger than %d MiB, decrypt it to a file instead", ErrFormat, maxHeldBuffer>>20)
+		}
+		return err
+	}
+
+	// anything after the end of the ciphertext is signed too
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("%w: %w", ErrReadChunk, err)
+	}
+	if err := sig.verify(h); err != nil {
+		return err
+	}
+
+	if held != nil {
+		if _, err := w.Write(held.buf); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+		}
+	}
+	return nil
+}
+
+func (o Options) decryptStream(pass []byte, r io.Reader, w io.Writer) error {
+	// create buffered io reader
+	reader := bufio.NewReaderSize(o.reader(r), internal.RWSize)
+
+	switch {
+	case isAge(reader):
+		if err := checkKeyfile(false, o.Keyfile); err != nil {
+			return err
+		}
+		return o.decryptAge(pass, reader, w)
+	case isOpenPGP(reader), isOpenSSL(reader):
+		if err := checkKeyfile(false, o.Keyfile); err != nil {
+			return err
+		}
+		return o.decryptLegacy(pass, reader, w)
+	}
+
+	header, err := readHeader(reader, o.MaxKDFMemory)
+	if err != nil {
+		return err
+	}
+
+	if err := checkKeyfile(header.keyfile, o.Keyfile); err != nil {
+		return err
+	}
+
+	keys := passphraseKeys(pass, o.Keyfile, header)
+	if len(header.stanzas) == 0 && len(pass) == 0 && len(o.Identities) > 0 {
+		// only an agent holding the passphrase can help
+		agent, err := agentKeys(o.Identities, o.Keyfile, header, keys.forms)
+		if err != nil {
+			return err
+		}
+		if agent == nil {
+			return fmt.Errorf("%w: file is encrypted with a passphrase", ErrAuth)
+		}
+		keys = agent
+	}
+	if len(header.stanzas) > 0 {
+		// the passphrase is tried last, deriving its key is slow
+		ids := o.Identities
+		if len(pass) > 0 {
+			ids = append(ids[:len(ids):len(ids)], &PassphraseIdentity{pass: pass, maxMemory: o.MaxKDFMemory})
+		}
+		keys = identityKeys(ids, header)
+	}
+	defer keys.wipe()
+
+	if err := decryptChunks(keys, header, reader, w, o.Metadata); err != nil {
+		return err
+	}
+
+	if o.PassphraseMatch != nil {
+		*o.PassphraseMatch = keys.match
+	}
+
+	return nil
+}
+
+// readHeader reads and decodes a header of either format version and checks
+// its key derivation parameters, allowing up to maxMemory KiB or the default
+// when zero.
+func readHeader(reader io.Reader, maxMemory uint32) (fileHeader, error) {
+	var header fileHeader
+
+	// read the magic first to tell the versions apart
+	magic := make([]byte, 4)
+	if err := readFull(reader, magic); err != nil {
+		return header, fmt.Errorf("%w: %w", ErrReadHeader, err)
+	}
+
+	switch [4]byte(magic) {
+	case internal.MagicHeader:
+
+		// create buffer of exact header size
+		headerBuf := make([]byte, internal.HeaderSize)
+		copy(headerBuf, magic)
+
+		// read the remaining 16 bytes of salt
+		if err := readFull(reader, headerBuf[4:]); err != nil {
+			return header, fmt.Errorf("%w: %w", ErrReadHeader, err)
+		}
+
+		v1, err := internal.DecodeHeader(headerBuf)
+		if err != nil {
+			return header, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+
+		header.version = 1
+		header.salt = v1.Salt[:]
+		header.kdf = internal.DefaultKDFParams()
+
+	case internal.MagicHeaderV2:
+		prefix := make([]byte, internal.HeaderV2PrefixSize)
+		copy(prefix, magic)
+
+		if err := readFull(reader, prefix[4:]); err != nil {
+			return header, fmt.Errorf("%w: %w", ErrReadHeader, err)
+		}
+
+		raw := make([]byte, internal.HeaderV2PrefixSize+int(binary.BigEndian.Uint16(prefix[4:6])))
+		copy(raw, prefix)
+
+		if err := readFull(reader, raw[internal.HeaderV2PrefixSize:]); err != nil {
+			return header, fmt.Errorf("%w: %w", ErrReadHeader, err)
+		}
+
+		v2, err := internal.DecodeHeaderV2(raw)
+		if err != nil {
+			return header, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+
+		header.version = 2
+		header.salt = v2.Salt[:]
+		header.kdf = v2.KDF
+		header.metadata = v2.Metadata
+		header.passForm = v2.PassForm
+		header.sparse = v2.Sparse
+		header.stanzas = v2.Stanzas
+		header.keyfile = v2.Keyfile
+		header.sum = sha256.Sum256(raw)
+
+	default:
+		return header, fmt.Errorf("%w: invalid magic: expected %q", ErrInvalidHeader, internal.MagicHeader)
+	}
+
+	// each passphrase stanza costs a key derivation up to the limit below
+	passphrases := 0
+	for _, st := range header.stanzas {
+		if st.Type == internal.StanzaPassphrase {
+			passphrases++
+		}
+	}
+	if passphrases > 1 {
+		return header, fmt.Errorf("%w: %d passphrase stanzas, at most one is allowed", ErrInvalidHeader, passphrases)
+	}
+
+	if maxMemory == 0 {
+		maxMemory = internal.DefaultMaxKDFMemory
+	}
+	if len(header.stanzas) == 0 {
+		if err := internal.CheckKDFParams(header.kdf, maxMemory); err != nil {
+			return header, fmt.Errorf("%w: %w", ErrKDFLimit, err)
+		}
+	}
+
+	return header, nil
+}
+
+func (h fileHeader) deriveKey(pass, keyfile []byte) []byte {
+	if h.version == 1 {
+		hash, _ := internal.GetArgon2ID(pass, h.salt)
+		return hash
+	}
+
+	hash := internal.DeriveKey(pass, h.salt, h.kdf)
+	if h.keyfile {
+		mixed := internal.MixKeyfile(hash, keyfile, h.salt)
+		internal.Wipe(hash)
+		return mixed
+	}
+	return hash
+}
+
+// decryptChunks decrypts the chunk stream following the header, taking the
+// first of keys that opens the first chunk.
+func decryptChunks(keys *keyCandidates, header fileHeader, reader *bufio.Reader, w io.Writer, md *Metadata) error {
+	writer := bufio.NewWriterSize(w, internal.RWSize)
+	holes := newZeroWriter(w)
+
+	gcm, _, err := keys.next()
+	if err != nil {
+		return err
+	}
+
+	final := false
+
+	for chunk := uint64(0); ; chunk++ {
+		// read 16 byte chunk header
+		chunkHeader, err := internal.ReadChunkHeader(reader)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrReadChunk, err)
+		}
+
+		if final {
+			return fmt.Errorf("%w: data after final chunk", ErrDecryptChunk)
+		}
+
+		length := chunkHeader.Length
+		zeros := false
+		var aad []byte
+		if header.version == 2 {
+			length, final, zeros = internal.SplitChunkLength(length)
+			if zeros && !header.sparse {
+				return fmt.Errorf("%w %d: run of zeros in a file without sparse chunks", ErrDecryptChunk, chunk)
+			}
+			aad = internal.ChunkAAD(header.sum, chunk, final, zeros)
+		}
+
+		// the length is untrusted, never allocate more than a full chunk
+		if length > internal.MaxChunkLength {
+			return fmt.Errorf("%w %d: length %d exceeds the maximum of %d", ErrDecryptChunk, chunk, length, internal.MaxChunkLength)
+		}
+
+		// create dynamic buffer to chunk size
+		buf := make([]byte, length)
+
+		// read full into chunk buffer
+		if err := readFull(reader, buf); err != nil {
+			return fmt.Errorf("%w: %w", ErrReadChunk, err)
+		}
+
+		// attempt to decrypt chunk using nonce in header, a bad first chunk
+		// is almost always the wrong passphrase so try the other candidates
+		plaintext, err := gcm.Open(nil, chunkHeader.Nonce[:], buf, aad)
+		for err != nil && chunk == 0 {
+			next, ok, keyErr := keys.next()
+			if keyErr != nil {
+				return keyErr
+			}
+			if !ok {
+				return fmt.Errorf("%w: %w", ErrAuth, err)
+			}
+			gcm = next
+			plaintext, err = gcm.Open(nil, chunkHeader.Nonce[:], buf, aad)
+		}
+		if err != nil {
+			return fmt.Errorf("%w %d: %w", ErrDecryptChunk, chunk, err)
+		}
+
+		// the first chunk holds metadata when the header says so
+		if chunk == 0 && header.metadata {
+			if md != nil {
+				if err := json.Unmarshal(plaintext, md); err != nil {
+					return fmt.Errorf("%w: invalid metadata: %w", ErrDecryptChunk, err)
+				}
+			}
+			continue
+		}
+
+		if zeros {
+			if len(plaintext) != 8 || binary.BigEndian.Uint64(plaintext) > math.MaxInt64 {
+				return fmt.Errorf("%w %d: invalid run of zeros", ErrDecryptChunk, chunk)
+			}
+			if err := holes.skip(writer, int64(binary.BigEndian.Uint64(plaintext))); err != nil {
+				return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+			}
+			continue
+		}
+
+		if _, err := writer.Write(plaintext); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+		}
+	}
+
+	// version 2 streams end with a marked chunk, anything else was cut short
+	if header.version == 2 && !final {
+		return fmt.Errorf("%w: %w", ErrReadChunk, ErrTruncated)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+	if err := holes.finish(); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteChunk, err)
+	}
+
+	return nil
+}
+
+// zeroWriter writes runs of zeros, as holes when the output is a file being
+// created by Decrypt and as zero bytes to any other writer.
+type zeroWriter struct {
+	file  *internal.AtomicFile
+	holes bool
+}
+
+func newZeroWriter(w io.Writer) *zeroWriter {
+	f, _ := w.(*internal.AtomicFile)
+	return &zeroWriter{file: f}
+}
+
+// skip writes n zeros after the buffered output of writer.
+func (z *zeroWriter) skip(writer *bufio.Writer, n int64) error {
+	if z.file != nil {
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if _, err := z.file.Seek(n, io.SeekCurrent); err != nil {
+			return err
+		}
+		z.holes = true
+		return nil
+	}
+
+	zeros := make([]byte, min(n, internal.RWSize))
+	for n > 0 {
+		k, err := writer.Write(zeros[:min(n, int64(len(zeros)))])
+		if err != nil {
+			return err
+		}
+		n -= int64(k)
+	}
+	return nil
+}
+
+// finish extends the file over a hole at its end, which seeking alone leaves out.
+func (z *zeroWriter) finish() error {
+	if !z.holes {
+		return nil
+	}
+	off, err := z.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	return z.file.Truncate(off)
+}
+
+// isZero reports whether every byte of b is zero.
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// readFull is io.ReadFull reporting any short read as ErrTruncated.
+func readFull(r io.Reader, buf []byte) error {
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrTruncated
+		}
+		return err
+	}
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	// create aes block
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNewCipher, err)
+	}
+
+	// create new gcm
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNewGcm, err)
+	}
+
+	return gcm, nil
+}
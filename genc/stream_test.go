@@ -0,0 +1,81 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+func TestPassphraseStanzaLimit(t *testing.T) {
+	plaintext := []byte("KEY=value\n")
+
+	tests := []struct {
+		name     string
+		stanzas  int
+		wantErr  error
+		maxDelay time.Duration
+	}{
+		{name: "one", stanzas: 1},
+		{name: "two", stanzas: 2, wantErr: ErrInvalidHeader},
+		{name: "many", stanzas: 600, wantErr: ErrInvalidHeader, maxDelay: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := fuzzStanzaFile(t, plaintext, tt.stanzas)
+
+			var out bytes.Buffer
+			start := time.Now()
+			err := DecryptStream(fuzzPass, bytes.NewReader(data), &out, Options{MaxKDFMemory: internal.MinKDFMemory})
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("DecryptStream: %v", err)
+				}
+				if !bytes.Equal(out.Bytes(), plaintext) {
+					t.Fatalf("got %q, want %q", out.Bytes(), plaintext)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), "passphrase stanzas") {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// refused before any key is derived
+			if tt.maxDelay > 0 && time.Since(start) > tt.maxDelay {
+				t.Fatalf("took %s to refuse", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestInspectPassphraseStanzaLimit(t *testing.T) {
+	data := fuzzStanzaFile(t, nil, 3)
+	if _, err := Inspect(bytes.NewReader(data)); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidHeader)
+	}
+	if err := DecryptStream(fuzzPass, bytes.NewReader(data), io.Discard); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidHeader)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// Strength is the result of CheckPassphrase: a score, the estimated number of
+// guesses and feedback on how to improve a weak passphrase.
+type Strength = internal.Strength
+
+// Passphrase strength scores, from easiest to hardest to guess.
+const (
+	VeryWeak = iota // fewer than 10^3 guesses
+	Weak            // fewer than 10^6 guesses
+	Fair            // fewer than 10^8 guesses
+	Good            // fewer than 10^10 guesses
+	Strong
+)
+
+var strengthNames = [...]string{"very-weak", "weak", "fair", "good", "strong"}
+
+// CheckPassphrase estimates how hard pass is to guess, looking for common
+// passwords and words, keyboard walks, repeats, sequences and dates.
+// userInputs are words an attacker knows, such as the file name.
+func CheckPassphrase(pass []byte, userInputs ...string) Strength {
+	return internal.EstimateStrength(pass, userInputs)
+}
+
+// StrengthName returns the CLI name of a score.
+func StrengthName(score int) string {
+	if score < VeryWeak || score > Strong {
+		return strconv.Itoa(score)
+	}
+	return strengthNames[score]
+}
+
+// ParseStrength parses a score given as 0 to 4 or by name.
+func ParseStrength(s string) (int, error) {
+	for score, name := range strengthNames {
+		if s == name || s == strconv.Itoa(score) {
+			return score, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid strength %q, must be 0-4, very-weak, weak, fair, good or strong", s)
+}
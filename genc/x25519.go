@@ -0,0 +1,177 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package genc
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+const (
+	x25519RecipientHRP = "genc"
+	x25519IdentityHRP  = "GENC-SECRET-KEY-"
+
+	// the same keys written the way age writes them
+	ageRecipientHRP = "age"
+	ageIdentityHRP  = "AGE-SECRET-KEY-"
+
+	// binds wrapping keys to this use of X25519
+	x25519Info = "go-enc/x25519"
+)
+
+// X25519Recipient is a public key files can be encrypted to, written as
+// genc1... Whoever encrypts to it cannot decrypt the file afterwards.
+type X25519Recipient struct {
+	pub *ecdh.PublicKey
+}
+
+// X25519Identity is the private key matching an X25519Recipient, written as
+// GENC-SECRET-KEY-1...
+type X25519Identity struct {
+	priv *ecdh.PrivateKey
+}
+
+// GenerateX25519Identity creates a new random identity.
+func GenerateX25519Identity() (*X25519Identity, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &X25519Identity{priv: priv}, nil
+}
+
+// ParseX25519Recipient parses a genc1... or age1... recipient.
+func ParseX25519Recipient(s string) (*X25519Recipient, error) {
+	hrp, data, err := internal.Bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRecipient, err)
+	}
+	if hrp != x25519RecipientHRP && hrp != ageRecipientHRP {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrRecipient, hrp)
+	}
+
+	pub, err := ecdh.X25519().NewPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRecipient, err)
+	}
+	return &X25519Recipient{pub: pub}, nil
+}
+
+// ParseX25519Identity parses a GENC-SECRET-KEY-1... or AGE-SECRET-KEY-1...
+// identity.
+func ParseX25519Identity(s string) (*X25519Identity, error) {
+	hrp, data, err := internal.Bech32Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIdentity, err)
+	}
+	defer internal.Wipe(data)
+
+	if hrp != strings.ToLower(x25519IdentityHRP) && hrp != strings.ToLower(ageIdentityHRP) {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrIdentity, hrp)
+	}
+
+	priv, err := ecdh.X25519().NewPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIdentity, err)
+	}
+	return &X25519Identity{priv: priv}, nil
+}
+
+// Recipient returns the public key matching i.
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{pub: i.priv.PublicKey()}
+}
+
+func (i *X25519Identity) String() string {
+	s, _ := internal.Bech32Encode(x25519IdentityHRP, i.priv.Bytes())
+	return s
+}
+
+func (r *X25519Recipient) String() string {
+	s, _ := internal.Bech32Encode(x25519RecipientHRP, r.pub.Bytes())
+	return s
+}
+
+// AgeString returns i as AGE-SECRET-KEY-1..., for use with age.
+func (i *X25519Identity) AgeString() string {
+	s, _ := internal.Bech32Encode(ageIdentityHRP, i.priv.Bytes())
+	return s
+}
+
+// AgeString returns r as age1..., for use with age.
+func (r *X25519Recipient) AgeString() string {
+	s, _ := internal.Bech32Encode(ageRecipientHRP, r.pub.Bytes())
+	return s
+}
+
+// wrap encrypts fileKey to an ephemeral key agreed with r.
+func (r *X25519Recipient) wrap(fileKey []byte) (internal.Stanza, error) {
+	eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+
+	shared, err := eph.ECDH(r.pub)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+	defer internal.Wipe(shared)
+
+	ephPub := eph.PublicKey().Bytes()
+
+	wrapped, err := sealFileKey(shared, x25519Salt(ephPub, r.pub), x25519Info, fileKey)
+	if err != nil {
+		return internal.Stanza{}, err
+	}
+
+	return internal.Stanza{Type: internal.StanzaX25519, Body: append(ephPub[:32:32], wrapped...)}, nil
+}
+
+// unwrap recovers the file key from a stanza addressed to i.
+func (i *X25519Identity) unwrap(st internal.Stanza) ([]byte, error) {
+	if st.Type != internal.StanzaX25519 {
+		return nil, errWrongIdentity
+	}
+	if len(st.Body) != 32+fileKeySize+internal.GCMTagSize {
+		return nil, fmt.Errorf("%w: invalid x25519 stanza length %d", ErrInvalidHeader, len(st.Body))
+	}
+
+	eph, err := ecdh.X25519().NewPublicKey(st.Body[:32])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+
+	shared, err := i.priv.ECDH(eph)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+	}
+	defer internal.Wipe(shared)
+
+	return openFileKey(shared, x25519Salt(st.Body[:32], i.priv.PublicKey()), x25519Info, st.Body[32:])
+}
+
+// x25519Salt binds a wrapping key to both public keys of the exchange.
+func x25519Salt(ephPub []byte, pub *ecdh.PublicKey) []byte {
+	salt := make([]byte, 0, 64)
+	salt = append(salt, ephPub...)
+	return append(salt, pub.Bytes()...)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package genc
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/irrisdev/go-enc/internal"
+)
+
+// flip replaces the character of s at i with another from the bech32 charset.
+func flip(s string, i int) string {
+	c := byte('q')
+	if s[i] == c {
+		c = 'p'
+	}
+	return s[:i] + string(c) + s[i+1:]
+}
+
+func TestParseX25519(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, identity := id.Recipient().String(), id.String()
+	short, err := internal.Bech32Encode(x25519RecipientHRP, make([]byte, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := internal.Bech32Encode("gencx", id.Recipient().pub.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		s        string
+		identity bool
+		err      error
+	}{
+		{name: "recipient", s: recipient},
+		{name: "age recipient", s: id.Recipient().AgeString()},
+		{name: "upper case recipient", s: strings.ToUpper(recipient)},
+		{name: "recipient bad checksum", s: flip(recipient, len(recipient)-1), err: ErrRecipient},
+		{name: "recipient bad data", s: flip(recipient, 10), err: ErrRecipient},
+		{name: "recipient truncated", s: recipient[:len(recipient)-1], err: ErrRecipient},
+		{name: "recipient mixed case", s: "GENC" + recipient[4:], err: ErrRecipient},
+		{name: "recipient short key", s: short, err: ErrRecipient},
+		{name: "recipient unknown type", s: other, err: ErrRecipient},
+		{name: "identity as recipient", s: identity, err: ErrRecipient},
+		{name: "empty recipient", s: "", err: ErrRecipient},
+
+		{name: "identity", s: identity, identity: true},
+		{name: "age identity", s: id.AgeString(), identity: true},
+		{name: "lower case identity", s: strings.ToLower(identity), identity: true},
+		{name: "identity bad checksum", s: flip(identity, len(identity)-1), identity: true, err: ErrIdentity},
+		{name: "identity bad data", s: flip(identity, 20), identity: true, err: ErrIdentity},
+		{name: "recipient as identity", s: recipient, identity: true, err: ErrIdentity},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.identity {
+				got, err := ParseX25519Identity(tc.s)
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("got %v, want %v", err, tc.err)
+				}
+				if err == nil && got.String() != identity {
+					t.Errorf("parsed as %s", got)
+				}
+				return
+			}
+
+			got, err := ParseX25519Recipient(tc.s)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if err == nil && got.String() != recipient {
+				t.Errorf("parsed as %s", got)
+			}
+		})
+	}
+}
+
+func TestX25519RoundTrip(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// encrypted to the age form, decrypted with the genc form
+	r, err := ParseRecipient(id.Recipient().AgeString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseX25519Identity(id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var enc bytes.Buffer
+	if err := EncryptStream(nil, strings.NewReader("KEY=value\n"), &enc, Options{Recipients: []Recipient{r}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := DecryptStream(nil, bytes.NewReader(enc.Bytes()), &out, Options{Identities: []Identity{parsed}}); err != nil || out.String() != "KEY=value\n" {
+		t.Fatalf("decrypted %q, %v", out.String(), err)
+	}
+	// only the matching identity unwraps the file key
+	if err := DecryptStream(nil, bytes.NewReader(enc.Bytes()), &out, Options{Identities: []Identity{other}}); !errors.Is(err, ErrNoIdentity) {
+		t.Fatalf("got %v, want %v", err, ErrNoIdentity)
+	}
+}
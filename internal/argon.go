@@ -18,6 +18,8 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package internal
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"runtime"
@@ -31,14 +33,64 @@ const (
 	iterations uint32 = 1
 )
 
-func GetArgon2ID(pass string, salt []byte) (key []byte, hash string) {
+// Bounds on argon2id parameters read from a file, so a crafted header can
+// neither demand absurd memory or time nor a key that is trivial to guess.
+const (
+	MinKDFMemory        uint32 = 8 * 1024    // 8 MiB
+	DefaultMaxKDFMemory uint32 = 1024 * 1024 // 1 GiB
+	MaxKDFIterations    uint32 = 16
+	MaxKDFThreads       uint8  = 16
+)
+
+// CheckKDFParams validates p against the bounds, allowing up to maxMemory KiB.
+func CheckKDFParams(p KDFParams, maxMemory uint32) error {
+	switch {
+	case p.Memory < MinKDFMemory:
+		return fmt.Errorf("memory %d KiB is below the minimum of %d KiB", p.Memory, MinKDFMemory)
+	case p.Memory > maxMemory:
+		return fmt.Errorf("memory %d KiB exceeds the limit of %d KiB", p.Memory, maxMemory)
+	case p.Iterations < 1 || p.Iterations > MaxKDFIterations:
+		return fmt.Errorf("iterations %d outside 1-%d", p.Iterations, MaxKDFIterations)
+	case p.Threads < 1 || p.Threads > MaxKDFThreads:
+		return fmt.Errorf("threads %d outside 1-%d", p.Threads, MaxKDFThreads)
+	}
+	return nil
+}
+
+// DefaultKDFParams returns the argon2id parameters used for new files.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{Memory: mem, Iterations: iterations, Threads: defaultThreads()}
+}
+
+// DeriveKey derives a 32 byte key from pass using argon2id with params p.
+func DeriveKey(pass []byte, salt []byte, p KDFParams) []byte {
+	return argon2.IDKey(pass, salt, p.Iterations, p.Memory, p.Threads, keyLen)
+}
+
+// MixKeyfile binds a derived passphrase key to the digest of a keyfile, so
+// neither alone gives the file key.
+func MixKeyfile(hash, keyfile, salt []byte) []byte {
+	ikm := make([]byte, 0, len(hash)+len(keyfile))
+	ikm = append(append(ikm, hash...), keyfile...)
+	defer Wipe(ikm)
+
+	key, _ := hkdf.Key(sha256.New, ikm, salt, "go-enc/keyfile", int(keyLen))
+	return key
+}
+
+func defaultThreads() uint8 {
 	// get cpu threads available
 	threads := uint8(runtime.NumCPU())
 	if threads > 4 {
 		threads = 4
 	}
+	return threads
+}
+
+func GetArgon2ID(pass []byte, salt []byte) (key []byte, hash string) {
+	threads := defaultThreads()
 
-	key = argon2.IDKey([]byte(pass), salt, iterations, mem, threads, keyLen)
+	key = argon2.IDKey(pass, salt, iterations, mem, threads, keyLen)
 
 	// base 64 for sprintf
 	bSalt := base64.RawStdEncoding.EncodeToString(salt)
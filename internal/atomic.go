@@ -0,0 +1,131 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AtomicFile is a temporary file created next to its target. Nothing is visible
+// at the target path until Commit renames the finished file into place.
+type AtomicFile struct {
+	*os.File
+	path string
+	done bool
+}
+
+// CreateAtomic creates a 0600 temporary file in the directory of path.
+func CreateAtomic(path string) (*AtomicFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AtomicFile{File: f, path: path}, nil
+}
+
+// Path returns the target path the file is committed to.
+func (f *AtomicFile) Path() string {
+	return f.path
+}
+
+// Commit fsyncs and closes the temporary file, renames it over the target and
+// fsyncs the parent directory so the rename itself is durable.
+func (f *AtomicFile) Commit() error {
+	if err := f.File.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := os.Rename(f.File.Name(), f.path); err != nil {
+		f.Abort()
+		return err
+	}
+
+	f.done = true
+
+	return SyncDir(filepath.Dir(f.path))
+}
+
+// Abort closes and removes the temporary file. It is a no-op after Commit.
+func (f *AtomicFile) Abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+
+	f.File.Close()
+	return os.Remove(f.File.Name())
+}
+
+// SyncDir fsyncs a directory so that entries created or renamed in it survive a crash.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// CommitNoReplace is like Commit but fails with an os.ErrExist error instead
+// of replacing a target that already exists.
+func (f *AtomicFile) CommitNoReplace() error {
+	if err := f.File.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.File.Close(); err != nil {
+		f.Abort()
+		return err
+	}
+
+	// link fails atomically if the target exists, unlike rename
+	err := os.Link(f.File.Name(), f.path)
+	switch {
+	case err == nil:
+		os.Remove(f.File.Name())
+	case os.IsExist(err):
+		f.Abort()
+		return err
+	default:
+		// filesystems without hard links fall back to check then rename
+		if _, statErr := os.Lstat(f.path); statErr == nil {
+			f.Abort()
+			return &os.LinkError{Op: "rename", Old: f.File.Name(), New: f.path, Err: os.ErrExist}
+		}
+		if err := os.Rename(f.File.Name(), f.path); err != nil {
+			f.Abort()
+			return err
+		}
+	}
+
+	f.done = true
+
+	return SyncDir(filepath.Dir(f.path))
+}
@@ -0,0 +1,139 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// dirNames lists the entries of dir.
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestAtomicAbortLeavesNothing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out")
+
+	f, err := CreateAtomic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("partial"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Abort(); err != nil {
+		t.Fatalf("Abort: %v", err)
+	}
+
+	if names := dirNames(t, dir); len(names) != 0 {
+		t.Fatalf("left behind %v", names)
+	}
+}
+
+func TestAtomicCommit(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []byte
+		commit   func(*AtomicFile) error
+		wantErr  error
+		want     []byte
+	}{
+		{name: "new", commit: (*AtomicFile).Commit, want: []byte("new")},
+		{name: "replace", existing: []byte("old"), commit: (*AtomicFile).Commit, want: []byte("new")},
+		{name: "no clobber new", commit: (*AtomicFile).CommitNoReplace, want: []byte("new")},
+		{name: "no clobber existing", existing: []byte("old"), commit: (*AtomicFile).CommitNoReplace, wantErr: fs.ErrExist, want: []byte("old")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "out")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, tt.existing, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f, err := CreateAtomic(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.WriteString("new"); err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.commit(f)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			// a no-op once committed or failed
+			if err := f.Abort(); err != nil {
+				t.Fatalf("Abort: %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("target holds %q, want %q", got, tt.want)
+			}
+			if names := dirNames(t, dir); len(names) != 1 {
+				t.Fatalf("temporary file left behind: %v", names)
+			}
+		})
+	}
+}
+
+func TestAtomicFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	path := filepath.Join(t.TempDir(), "out")
+
+	f, err := CreateAtomic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		t.Fatalf("committed with mode %v, readable by others", perm)
+	}
+}
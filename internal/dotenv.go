@@ -0,0 +1,155 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+// ParseDotenv parses dotenv syntax: KEY=value lines with optional "export"
+// prefixes, # comments, 'literal' values and "escaped" values that may span
+// several lines. Variables are returned in file order.
+func ParseDotenv(data []byte) ([]EnvVar, error) {
+	var vars []EnvVar
+
+	src := strings.ReplaceAll(string(data), "\r\n", "\n")
+	line := 1
+
+	for len(src) > 0 {
+		// take the next line
+		raw, rest, _ := strings.Cut(src, "\n")
+		trimmed := strings.TrimSpace(raw)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			src = rest
+			line++
+			continue
+		}
+
+		// export may be followed by any run of spaces and tabs
+		if rest, ok := strings.CutPrefix(trimmed, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			trimmed = strings.TrimLeft(rest, " \t")
+		}
+		key, _, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !validEnvKey(key) {
+			return nil, fmt.Errorf("dotenv line %d: expected KEY=value", line)
+		}
+
+		// quoted values may span several lines so parse from the raw input
+		off := strings.IndexByte(raw, '=') + 1
+		for off < len(raw) && (raw[off] == ' ' || raw[off] == '\t') {
+			off++
+		}
+
+		value, n, err := parseEnvValue(src[off:])
+		if err != nil {
+			return nil, fmt.Errorf("dotenv line %d: %w", line, err)
+		}
+
+		// only a comment may follow a quoted value
+		tail, next, _ := strings.Cut(src[off+n:], "\n")
+		if tail = strings.TrimSpace(tail); tail != "" && !strings.HasPrefix(tail, "#") {
+			return nil, fmt.Errorf("dotenv line %d: unexpected characters after value", line)
+		}
+
+		vars = append(vars, EnvVar{Key: key, Value: value})
+
+		line += strings.Count(src[off:off+n], "\n") + 1
+		src = next
+	}
+
+	return vars, nil
+}
+
+// parseEnvValue parses a single value and reports how many bytes of s it used.
+func parseEnvValue(s string) (string, int, error) {
+	if s == "" {
+		return "", 0, nil
+	}
+
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated single quoted value")
+		}
+		return s[1 : end+1], end + 2, nil
+
+	case '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c == '"':
+				return b.String(), i + 1, nil
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", 0, fmt.Errorf("unterminated double quoted value")
+	}
+
+	// unquoted values end at the line break or an inline comment, a # at
+	// their start or after a space or tab
+	end := strings.IndexByte(s, '\n')
+	if end < 0 {
+		end = len(s)
+	}
+	for i := 0; i < end; i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	return strings.TrimSpace(s[:end]), end, nil
+}
+
+func validEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		case c == '.' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
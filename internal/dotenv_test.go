@@ -0,0 +1,108 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []EnvVar
+	}{
+		{name: "empty", in: ""},
+		{name: "plain", in: "KEY=value\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "no final newline", in: "A=1\nB=2", want: []EnvVar{{"A", "1"}, {"B", "2"}}},
+		{name: "crlf", in: "A=1\r\nB=2\r\n", want: []EnvVar{{"A", "1"}, {"B", "2"}}},
+		{name: "empty value", in: "KEY=\n", want: []EnvVar{{"KEY", ""}}},
+		{name: "spaces around", in: "  KEY = value  \n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "equals in value", in: "URL=a=b&c=d\n", want: []EnvVar{{"URL", "a=b&c=d"}}},
+		{name: "lowercase and dots", in: "app.port_2=80\n", want: []EnvVar{{"app.port_2", "80"}}},
+
+		{name: "export", in: "export KEY=value\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "export extra spaces", in: "export   KEY=value\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "export tab", in: "export\tKEY=value\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "export tabs and spaces", in: "export \t KEY=value\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "export as a key", in: "export=1\n", want: []EnvVar{{"export", "1"}}},
+		{name: "export prefix in key", in: "exported=1\n", want: []EnvVar{{"exported", "1"}}},
+
+		{name: "comment lines", in: "# a comment\n\n  # indented\nKEY=value\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "inline comment", in: "KEY=value # comment\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "inline comment after tab", in: "KEY=value\t# comment\n", want: []EnvVar{{"KEY", "value"}}},
+		{name: "comment as value", in: "KEY= # comment\nNEXT=1\n", want: []EnvVar{{"KEY", ""}, {"NEXT", "1"}}},
+		{name: "comment right after equals", in: "KEY=#comment\n", want: []EnvVar{{"KEY", ""}}},
+		{name: "hash inside value", in: "KEY=a#b\n", want: []EnvVar{{"KEY", "a#b"}}},
+		{name: "comment after quotes", in: "KEY=\"a # b\" # comment\n", want: []EnvVar{{"KEY", "a # b"}}},
+
+		{name: "single quotes are literal", in: `KEY='a\nb $HOME'` + "\n", want: []EnvVar{{"KEY", `a\nb $HOME`}}},
+		{name: "double quote escapes", in: `KEY="a\nb\tc\"d\\e"` + "\n", want: []EnvVar{{"KEY", "a\nb\tc\"d\\e"}}},
+		{name: "multiline double quotes", in: "KEY=\"line 1\nline 2\"\nNEXT=1\n", want: []EnvVar{{"KEY", "line 1\nline 2"}, {"NEXT", "1"}}},
+		{name: "multiline single quotes", in: "KEY='-----BEGIN\nabc\n-----END'\n", want: []EnvVar{{"KEY", "-----BEGIN\nabc\n-----END"}}},
+		{name: "quoted spaces kept", in: "KEY='  padded  '\n", want: []EnvVar{{"KEY", "  padded  "}}},
+
+		// later ones win once they are in the environment
+		{name: "duplicate keys in order", in: "KEY=1\nOTHER=x\nKEY=2\n", want: []EnvVar{{"KEY", "1"}, {"OTHER", "x"}, {"KEY", "2"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("ParseDotenv: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{name: "no equals", in: "KEY\n"},
+		{name: "empty key", in: "=value\n"},
+		{name: "key starts with digit", in: "1KEY=value\n"},
+		{name: "space in key", in: "MY KEY=value\n"},
+		{name: "dash in key", in: "MY-KEY=value\n"},
+		{name: "export alone", in: "export KEY\n"},
+		{name: "export tab alone", in: "export\tKEY\n"},
+		{name: "unterminated double quote", in: "KEY=\"value\n"},
+		{name: "unterminated single quote", in: "KEY='value\nNEXT=1\n"},
+		{name: "text after quotes", in: "KEY=\"value\" trailing\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := ParseDotenv([]byte(tt.in)); err == nil {
+				t.Fatalf("got %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrorLine(t *testing.T) {
+	_, err := ParseDotenv([]byte("A=1\nB=\"two\nlines\"\n\nbad line\n"))
+	if err == nil || err.Error() != "dotenv line 5: expected KEY=value" {
+		t.Fatalf("got %v, want the error on line 5", err)
+	}
+}
@@ -18,6 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package internal
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 )
@@ -75,3 +76,214 @@ func DecodeHeader(buf []byte) (Header, error) {
 
 	return header, nil
 }
+
+func EncodeHeaderV2(h HeaderV2) []byte {
+	var body []byte
+
+	// the key is either derived from a passphrase or wrapped in stanzas
+	if len(h.Stanzas) == 0 {
+		body = appendField(body, FieldSalt, h.Salt[:])
+		body = appendField(body, FieldKDF, EncodeKDFParams(h.KDF))
+	}
+
+	for _, st := range h.Stanzas {
+		body = appendField(body, FieldStanza, append([]byte{st.Type}, st.Body...))
+	}
+
+	if h.Metadata {
+		body = appendField(body, FieldMetadata, nil)
+	}
+
+	if h.PassForm != PassRaw {
+		body = appendField(body, FieldPassForm, []byte{byte(h.PassForm)})
+	}
+
+	if h.Sparse {
+		body = appendField(body, FieldSparse, nil)
+	}
+
+	if h.Keyfile {
+		body = appendField(body, FieldKeyfile, nil)
+	}
+
+	buf := make([]byte, HeaderV2PrefixSize, HeaderV2PrefixSize+len(body))
+	copy(buf[:4], MagicHeaderV2[:])
+	binary.BigEndian.PutUint16(buf[4:6], uint16(len(body)))
+
+	return append(buf, body...)
+}
+
+// EncodeKDFParams encodes p as memory and iterations as big endian uint32
+// followed by the threads byte.
+func EncodeKDFParams(p KDFParams) []byte {
+	buf := make([]byte, KDFParamsSize)
+	binary.BigEndian.PutUint32(buf[0:4], p.Memory)
+	binary.BigEndian.PutUint32(buf[4:8], p.Iterations)
+	buf[8] = p.Threads
+	return buf
+}
+
+// DecodeKDFParams reverses EncodeKDFParams.
+func DecodeKDFParams(buf []byte) (KDFParams, error) {
+	if len(buf) != KDFParamsSize {
+		return KDFParams{}, fmt.Errorf("invalid kdf parameters length %d", len(buf))
+	}
+	return KDFParams{
+		Memory:     binary.BigEndian.Uint32(buf[0:4]),
+		Iterations: binary.BigEndian.Uint32(buf[4:8]),
+		Threads:    buf[8],
+	}, nil
+}
+
+func appendField(buf []byte, typ byte, value []byte) []byte {
+	buf = append(buf, typ)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(value)))
+	return append(buf, value...)
+}
+
+// DecodeHeaderV2 decodes a complete version 2 header, prefix included.
+func DecodeHeaderV2(buf []byte) (HeaderV2, error) {
+	var header HeaderV2
+
+	if len(buf) < HeaderV2PrefixSize {
+		return header, fmt.Errorf("buffer too small, need: %d bytes, got: %d", HeaderV2PrefixSize, len(buf))
+	}
+	if [4]byte(buf[:4]) != MagicHeaderV2 {
+		return header, fmt.Errorf("invalid magic: expected %q", MagicHeaderV2)
+	}
+	if n := int(binary.BigEndian.Uint16(buf[4:6])); len(buf) != HeaderV2PrefixSize+n {
+		return header, fmt.Errorf("header length mismatch, need: %d bytes, got: %d", HeaderV2PrefixSize+n, len(buf))
+	}
+
+	header.Raw = buf
+	seen := map[byte]bool{}
+
+	for body := buf[HeaderV2PrefixSize:]; len(body) > 0; {
+		if len(body) < FieldHeaderSize {
+			return header, fmt.Errorf("truncated header field")
+		}
+
+		typ := body[0]
+		n := int(binary.BigEndian.Uint16(body[1:3]))
+		if len(body) < FieldHeaderSize+n {
+			return header, fmt.Errorf("truncated header field %d", typ)
+		}
+		value := body[FieldHeaderSize : FieldHeaderSize+n]
+		body = body[FieldHeaderSize+n:]
+
+		if seen[typ] && typ != FieldStanza {
+			return header, fmt.Errorf("duplicate header field %d", typ)
+		}
+		seen[typ] = true
+
+		switch typ {
+		case FieldSalt:
+			if n != len(header.Salt) {
+				return header, fmt.Errorf("invalid salt length %d", n)
+			}
+			copy(header.Salt[:], value)
+		case FieldKDF:
+			kdf, err := DecodeKDFParams(value)
+			if err != nil {
+				return header, err
+			}
+			header.KDF = kdf
+		case FieldMetadata:
+			if n != 0 {
+				return header, fmt.Errorf("invalid metadata field length %d", n)
+			}
+			header.Metadata = true
+		case FieldPassForm:
+			if n != 1 || PassForm(value[0]) != PassNFC {
+				return header, fmt.Errorf("unsupported passphrase normalisation")
+			}
+			header.PassForm = PassForm(value[0])
+		case FieldSparse:
+			if n != 0 {
+				return header, fmt.Errorf("invalid sparse field length %d", n)
+			}
+			header.Sparse = true
+		case FieldStanza:
+			if n < 1 {
+				return header, fmt.Errorf("invalid stanza length %d", n)
+			}
+			header.Stanzas = append(header.Stanzas, Stanza{Type: value[0], Body: value[1:]})
+		case FieldKeyfile:
+			if n != 0 {
+				return header, fmt.Errorf("invalid keyfile field length %d", n)
+			}
+			header.Keyfile = true
+		default:
+			// unknown fields may change how the file must be decrypted
+			return header, fmt.Errorf("unsupported header field %d", typ)
+		}
+	}
+
+	passphrase := seen[FieldSalt] || seen[FieldKDF]
+	switch {
+	case passphrase && seen[FieldStanza]:
+		return header, fmt.Errorf("header has both passphrase parameters and key stanzas")
+	case passphrase && (!seen[FieldSalt] || !seen[FieldKDF]):
+		return header, fmt.Errorf("header is missing salt or kdf parameters")
+	case !passphrase && !seen[FieldStanza]:
+		return header, fmt.Errorf("header has no key")
+	case header.Keyfile && !passphrase:
+		return header, fmt.Errorf("keyfile field without passphrase parameters")
+	}
+
+	return header, nil
+}
+
+// ChunkAAD binds a version 2 chunk to its header, position, finality and
+// whether it is a run of zeros.
+func ChunkAAD(headerSum [sha256.Size]byte, index uint64, final, zeros bool) []byte {
+	aad := make([]byte, 0, sha256.Size+9)
+	aad = append(aad, headerSum[:]...)
+	aad = binary.BigEndian.AppendUint64(aad, index)
+
+	var flags byte
+	if final {
+		flags |= 1
+	}
+	if zeros {
+		flags |= 2
+	}
+	return append(aad, flags)
+}
+
+// SplitChunkLength separates the final and zeros flags from a version 2 chunk length.
+func SplitChunkLength(length uint32) (uint32, bool, bool) {
+	return length &^ (ChunkFinal | ChunkZeros), length&ChunkFinal != 0, length&ChunkZeros != 0
+}
+
+// JoinChunkLength sets the final and zeros flags on a version 2 chunk length.
+func JoinChunkLength(length int, final, zeros bool) (uint32, error) {
+	if length < 0 || length >= ChunkZeros {
+		return 0, fmt.Errorf("chunk length %d too large", length)
+	}
+
+	l := uint32(length)
+	if final {
+		l |= ChunkFinal
+	}
+	if zeros {
+		l |= ChunkZeros
+	}
+	return l, nil
+}
+
+// EncryptedSize returns the size of a version 2 file with header h holding
+// plain bytes in data chunks and a metadata chunk of metadata bytes, or none
+// when metadata is negative.
+func EncryptedSize(h HeaderV2, plain int64, metadata int) int64 {
+	overhead := int64(ChunkHeaderSize + GCMTagSize)
+
+	// an empty input still gets a final chunk
+	chunks := max((plain+ChunkSize-1)/ChunkSize, 1)
+
+	size := int64(len(EncodeHeaderV2(h))) + plain + chunks*overhead
+	if metadata >= 0 {
+		size += int64(metadata) + overhead
+	}
+	return size
+}
@@ -0,0 +1,47 @@
+//go:build !unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"io/fs"
+	"os"
+)
+
+const oNoFollow = 0
+
+// OpenNoFollow opens path for reading, symlinks are checked by the caller only.
+func OpenNoFollow(path string) (*os.File, error) {
+	return os.Open(path)
+}
+
+// LinkCount is not available on this platform and always returns 1.
+func LinkCount(info fs.FileInfo) uint64 {
+	return 1
+}
+
+// AllocatedSize is not available on this platform and returns the file size.
+func AllocatedSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
+
+// SharedDir is not detected on this platform and always returns false.
+func SharedDir(dir string) bool {
+	return false
+}
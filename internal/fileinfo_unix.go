@@ -0,0 +1,74 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// oNoFollow makes opening a path fail when its last element is a symlink.
+const oNoFollow = unix.O_NOFOLLOW
+
+// OpenNoFollow opens path for reading without following a final symlink or
+// blocking on a FIFO that was swapped in after path was inspected.
+func OpenNoFollow(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// reads on regular files never block, clear the flag again
+	if err := unix.SetNonblock(int(f.Fd()), false); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// LinkCount returns the number of hard links to the file described by info.
+func LinkCount(info fs.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
+
+// AllocatedSize returns the bytes of disk actually used by the file described
+// by info, less than its size when it has holes.
+func AllocatedSize(info fs.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return min(int64(st.Blocks)*512, info.Size())
+	}
+	return info.Size()
+}
+
+// SharedDir reports whether other users may create entries in dir, because it
+// is sticky like /tmp or world writable.
+func SharedDir(dir string) bool {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	return info.Mode()&fs.ModeSticky != 0 || info.Mode().Perm()&0o002 != 0
+}
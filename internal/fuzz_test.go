@@ -0,0 +1,82 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+)
+
+func FuzzDecodeHeader(f *testing.F) {
+	f.Add(EncodeHeader(nil, make([]byte, 16)))
+	f.Add(EncodeHeaderV2(HeaderV2{KDF: DefaultKDFParams()}))
+	f.Add(EncodeHeaderV2(HeaderV2{KDF: DefaultKDFParams(), Metadata: true, PassForm: PassNFC}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if h, err := DecodeHeader(data); err == nil && h.Magic != MagicHeader {
+			t.Fatalf("accepted magic %q", h.Magic)
+		}
+
+		h, err := DecodeHeaderV2(data)
+		if err != nil {
+			return
+		}
+
+		// whatever was accepted must survive encoding and decoding again
+		again, err := DecodeHeaderV2(EncodeHeaderV2(h))
+		if err != nil {
+			t.Fatalf("re-encoded header rejected: %v", err)
+		}
+		if again.Salt != h.Salt || again.KDF != h.KDF || again.Metadata != h.Metadata || again.PassForm != h.PassForm ||
+			again.Sparse != h.Sparse || again.Keyfile != h.Keyfile || len(again.Stanzas) != len(h.Stanzas) {
+			t.Fatalf("header changed when re-encoded: %+v, got %+v", h, again)
+		}
+		for i, st := range h.Stanzas {
+			if again.Stanzas[i].Type != st.Type || !bytes.Equal(again.Stanzas[i].Body, st.Body) {
+				t.Fatalf("stanza %d changed when re-encoded", i)
+			}
+		}
+	})
+}
+
+func FuzzReadChunkHeader(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(EncodeChunkHeader(ChunkFinal|16, make([]byte, 12)))
+	f.Add(EncodeChunkHeader(0xffffffff, make([]byte, 12)))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		h, err := ReadChunkHeader(bufio.NewReader(bytes.NewReader(data)))
+
+		switch {
+		case len(data) == 0:
+			if err != io.EOF {
+				t.Fatalf("empty input gave %v, want EOF", err)
+			}
+		case len(data) < ChunkHeaderSize:
+			if err != io.ErrUnexpectedEOF {
+				t.Fatalf("short input gave %v, want unexpected EOF", err)
+			}
+		case err != nil:
+			t.Fatalf("full header rejected: %v", err)
+		case !bytes.Equal(EncodeChunkHeader(h.Length, h.Nonce[:]), data[:ChunkHeaderSize]):
+			t.Fatalf("chunk header does not round trip")
+		}
+	})
+}
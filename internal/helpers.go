@@ -21,7 +21,9 @@ import (
 	"bufio"
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"io"
+	"math"
 	"os"
 )
 
@@ -38,9 +40,12 @@ func ReadChunkHeader(reader *bufio.Reader) (ChunkHeader, error) {
 		return header, io.EOF
 	}
 	// partial read == error
-	if n < ChunkHeaderSize && err != nil {
+	if err == io.ErrUnexpectedEOF {
 		return header, io.ErrUnexpectedEOF
 	}
+	if err != nil {
+		return header, err
+	}
 
 	// decode header correctly
 	header, headerErr := DecodeChunkHeader(buf)
@@ -72,10 +77,12 @@ func SaltToString(salt []byte) string {
 }
 
 func FileExists(path string) bool {
-	_, err := os.Stat(path)
+	_, err := os.Lstat(path)
 	return !os.IsNotExist(err)
 }
 
+// CopyFile copies src to dst with the mode and timestamps of src. dst is
+// created 0600 and only widened to the mode of src once the copy is complete.
 func CopyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
@@ -83,7 +90,8 @@ func CopyFile(src, dst string) error {
 	}
 	defer in.Close()
 
-	out, err := os.Create(dst)
+	// never write through a symlink planted at dst
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|oNoFollow, 0o600)
 	if err != nil {
 		return err
 	}
@@ -93,9 +101,84 @@ func CopyFile(src, dst string) error {
 		return err
 	}
 
-	info, err := os.Stat(src)
+	info, err := in.Stat()
 	if err != nil {
 		return err
 	}
-	return os.Chmod(dst, info.Mode())
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// ShredFile overwrites the contents of path with random data the given number
+// of passes, syncing after each pass, and then removes it.
+func ShredFile(path string, passes int) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|oNoFollow, 0)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	for i := 0; i < passes; i++ {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// BackupPath returns the path to back up path to, path.bak or the next free
+// path.bak.N when numbered.
+func BackupPath(path string, numbered bool) (string, error) {
+	if !numbered {
+		return path + ".bak", nil
+	}
+
+	for i := 1; i < math.MaxInt32; i++ {
+		candidate := fmt.Sprintf("%s.bak.%d", path, i)
+		if !FileExists(candidate) {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free backup name for %s", path)
+}
+
+// FormatSize formats n bytes with binary units, like 1.5 GiB.
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
 }
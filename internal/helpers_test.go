@@ -0,0 +1,79 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShredFile(t *testing.T) {
+	original := bytes.Repeat([]byte("KEY=value\n"), 1000)
+
+	for _, passes := range []int{0, 1, 3} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "secrets")
+		if err := os.WriteFile(path, original, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// a second name for the same blocks shows what shredding left in them
+		if err := os.Link(path, filepath.Join(dir, "link")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ShredFile(path, passes); err != nil {
+			t.Fatalf("%d passes: %v", passes, err)
+		}
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%d passes: file still exists: %v", passes, err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(dir, "link"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(original) {
+			t.Errorf("%d passes: size changed to %d, want %d", passes, len(got), len(original))
+		}
+		if overwritten := !bytes.Equal(got, original); overwritten != (passes > 0) {
+			t.Errorf("%d passes: overwritten %v", passes, overwritten)
+		}
+	}
+}
+
+func TestShredFileRefusesSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte("KEY=value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ShredFile(link, 1); err == nil {
+		t.Fatal("shredded through a symlink")
+	}
+	if got, err := os.ReadFile(target); err != nil || string(got) != "KEY=value\n" {
+		t.Errorf("target changed to %q: %v", got, err)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval is how often a waiting lock is retried.
+const lockPollInterval = 100 * time.Millisecond
+
+// LockedError is returned when another process holds a lock.
+type LockedError struct {
+	Path string
+	PID  int // 0 when unknown
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%s is in use by another process", e.Path)
+	}
+	return fmt.Sprintf("%s is in use by pid %d", e.Path, e.PID)
+}
+
+// FileLock is an exclusive advisory lock on a path. It is held on a hidden
+// sidecar file next to path, see LockPath, so paths that do not exist yet can
+// be locked too, and the file records the pid of the holder.
+type FileLock struct {
+	f    *os.File
+	name string
+}
+
+// LockFile locks path. Without wait a held lock fails with *LockedError,
+// with wait it is retried until it is free or ctx is done.
+func LockFile(ctx context.Context, path string, wait bool) (*FileLock, error) {
+	name := LockPath(path)
+
+	for {
+		l, err := tryLock(name)
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			return l, nil
+		}
+
+		if !wait {
+			return nil, &LockedError{Path: path, PID: lockHolder(name)}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// LockPath returns the sidecar lock file of path, dir/.base.go-enc-lock.
+func LockPath(path string) string {
+	dir, base := filepath.Split(path)
+	return filepath.Join(dir, "."+base+".go-enc-lock")
+}
+
+// tryLock returns a nil lock when it is held elsewhere.
+func tryLock(name string) (*FileLock, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o600)
+	writable := err == nil
+	if errors.Is(err, fs.ErrPermission) {
+		// another user's lock file can still be locked, just not written
+		f, err = os.Open(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := flock(f)
+	if err != nil || !ok {
+		f.Close()
+		return nil, err
+	}
+
+	// the previous holder removes the file before releasing it, a lock on
+	// a file that is no longer at name protects nothing
+	held, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	current, err := os.Stat(name)
+	if err != nil || !os.SameFile(held, current) {
+		f.Close()
+		return tryLock(name)
+	}
+
+	if writable {
+		if err := f.Truncate(0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if _, err := f.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return &FileLock{f: f, name: name}, nil
+}
+
+// lockHolder reads the pid recorded in a lock file, 0 if there is none.
+func lockHolder(name string) int {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// Unlock removes the lock file and releases the lock.
+func (l *FileLock) Unlock() error {
+	if l == nil {
+		return nil
+	}
+	err := os.Remove(l.name)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// LockFiles locks every path in a fixed order so two processes locking the
+// same files cannot deadlock. Paths in directories where the lock file cannot
+// be created, such as read only media, are left unlocked. The returned
+// function releases all locks.
+func LockFiles(ctx context.Context, wait bool, paths ...string) (func(), error) {
+	type target struct{ path, abs string }
+
+	targets := make([]target, 0, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target{p, abs})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].abs < targets[j].abs })
+
+	var locks []*FileLock
+	unlock := func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+
+	for i, t := range targets {
+		if i > 0 && t.abs == targets[i-1].abs {
+			continue
+		}
+
+		l, err := LockFile(ctx, t.path, wait)
+		if errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS) {
+			continue
+		}
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		locks = append(locks, l)
+	}
+
+	return unlock, nil
+}
@@ -0,0 +1,136 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockNoWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+
+	held, err := LockFile(context.Background(), path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LockFile(context.Background(), path, false)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("got %v, want *LockedError", err)
+	}
+	if locked.PID != os.Getpid() {
+		t.Fatalf("holder pid %d, want %d", locked.PID, os.Getpid())
+	}
+
+	if err := held.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(LockPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+
+	again, err := LockFile(context.Background(), path, false)
+	if err != nil {
+		t.Fatalf("lock after unlock: %v", err)
+	}
+	again.Unlock()
+}
+
+func TestLockWait(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+
+	tests := []struct {
+		name    string
+		release bool
+		cancel  bool
+	}{
+		{name: "released", release: true},
+		{name: "cancelled", cancel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			held, err := LockFile(context.Background(), path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer held.Unlock()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go func() {
+				time.Sleep(2 * lockPollInterval)
+				if tt.release {
+					held.Unlock()
+				}
+				if tt.cancel {
+					cancel()
+				}
+			}()
+
+			done := make(chan error, 1)
+			go func() {
+				l, err := LockFile(ctx, path, true)
+				l.Unlock()
+				done <- err
+			}()
+
+			select {
+			case err := <-done:
+				if tt.release && err != nil {
+					t.Fatalf("waiting for a released lock: %v", err)
+				}
+				if tt.cancel && !errors.Is(err, context.Canceled) {
+					t.Fatalf("got %v, want %v", err, context.Canceled)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("still waiting")
+			}
+		})
+	}
+}
+
+func TestLockFilesOrderAndDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+
+	// the same file twice is locked once rather than deadlocking
+	unlock, err := LockFiles(context.Background(), false, b, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LockFiles(context.Background(), false, a); err == nil {
+		t.Fatal("second lock on a held file")
+	}
+
+	unlock()
+	for _, p := range []string{a, b} {
+		if _, err := os.Stat(LockPath(p)); !os.IsNotExist(err) {
+			t.Fatalf("lock file of %s left behind: %v", p, err)
+		}
+	}
+}
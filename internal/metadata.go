@@ -0,0 +1,77 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"time"
+)
+
+// Metadata is the file metadata stored encrypted inside a .genc file.
+type Metadata struct {
+	Mode   fs.FileMode       `json:"mode"`
+	Uid    *int              `json:"uid,omitempty"`
+	Gid    *int              `json:"gid,omitempty"`
+	Atime  time.Time         `json:"atime"`
+	Mtime  time.Time         `json:"mtime"`
+	Xattrs map[string][]byte `json:"xattrs,omitempty"`
+}
+
+// CaptureMetadata reads the metadata of an open file.
+func CaptureMetadata(f *os.File) (Metadata, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	md := Metadata{
+		Mode:  info.Mode().Perm(),
+		Atime: info.ModTime(),
+		Mtime: info.ModTime(),
+	}
+
+	captureOwner(f, &md)
+
+	xattrs, err := readXattrs(f)
+	if err != nil {
+		log.Printf("failed to read extended attributes of %s: %v\n", f.Name(), err)
+	}
+	md.Xattrs = xattrs
+
+	return md, nil
+}
+
+// RestoreMetadata applies md to an open file. Ownership that the process is
+// not allowed to set is skipped with a warning rather than failing.
+func RestoreMetadata(f *os.File, md Metadata) error {
+	if err := f.Chmod(md.Mode.Perm()); err != nil {
+		return err
+	}
+
+	if err := restoreOwner(f, md); err != nil {
+		log.Printf("failed to restore owner of %s: %v\n", f.Name(), err)
+	}
+
+	if err := writeXattrs(f, md.Xattrs); err != nil {
+		log.Printf("failed to restore extended attributes of %s: %v\n", f.Name(), err)
+	}
+
+	return os.Chtimes(f.Name(), md.Atime, md.Mtime)
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func captureOwner(f *os.File, md *Metadata) {
+	var st unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &st); err != nil {
+		return
+	}
+
+	uid, gid := int(st.Uid), int(st.Gid)
+	md.Uid, md.Gid = &uid, &gid
+	md.Atime = time.Unix(st.Atim.Unix())
+}
+
+func restoreOwner(f *os.File, md Metadata) error {
+	if md.Uid == nil || md.Gid == nil {
+		return nil
+	}
+
+	var st unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &st); err != nil {
+		return err
+	}
+	if int(st.Uid) == *md.Uid && int(st.Gid) == *md.Gid {
+		return nil
+	}
+
+	return f.Chown(*md.Uid, *md.Gid)
+}
@@ -22,10 +22,39 @@ const (
 	ChunkHeaderSize = 16        // bytes
 	RWSize          = 64 * 1024 // 64 KB
 	ChunkSize       = 1 << 20   // 1 MiB
+	GCMTagSize      = 16        // bytes
+
+	// MaxChunkLength bounds the ciphertext length read from a chunk header,
+	// a full chunk plus the GCM tag
+	MaxChunkLength = ChunkSize + GCMTagSize
+)
+
+// format version 2 headers are a magic, a uint16 body length and a list of
+// type, uint16 length, value fields
+const (
+	HeaderV2PrefixSize = 6 // bytes
+	FieldHeaderSize    = 3 // bytes
+	KDFParamsSize      = 9 // bytes
+
+	FieldSalt     = 0x01 // 16 byte argon2id salt
+	FieldKDF      = 0x02 // argon2id parameters
+	FieldMetadata = 0x03 // empty, the first chunk holds encrypted file metadata
+	FieldPassForm = 0x04 // 1 byte, unicode normalisation of the passphrase
+	FieldSparse   = 0x05 // empty, runs of zero chunks are stored as their length
+	FieldStanza   = 0x06 // stanza type byte and body, may repeat
+	FieldKeyfile  = 0x07 // empty, the key also depends on a keyfile
+
+	// high bit of a version 2 chunk length marks the final chunk
+	ChunkFinal = 1 << 31
+	// the next bit marks a run of zeros in sparse files, the chunk holds
+	// the length of the run as a big endian uint64
+	ChunkZeros = 1 << 30
 )
 
 var MagicHeader = [4]byte{'g', 'e', 'n', 'c'}
 
+var MagicHeaderV2 = [4]byte{'g', 'e', 'n', '2'}
+
 type Header struct {
 	Magic [4]byte
 	Salt  [16]byte
@@ -35,3 +64,43 @@ type ChunkHeader struct {
 	Nonce  [12]byte
 	Length uint32
 }
+
+type KDFParams struct {
+	Memory     uint32 // KiB
+	Iterations uint32
+	Threads    uint8
+}
+
+type HeaderV2 struct {
+	Salt     [16]byte
+	KDF      KDFParams
+	Metadata bool
+
+	// PassForm is how the passphrase was normalised before key derivation,
+	// headers without the field used the raw bytes
+	PassForm PassForm
+	Sparse   bool
+	// Stanzas wrap a random file key for each recipient, files without
+	// them derive the key from a passphrase with Salt and KDF
+	Stanzas []Stanza
+	// Keyfile is set when the passphrase key is mixed with a keyfile
+	Keyfile bool
+
+	// Raw is the encoded header, every chunk is authenticated against it
+	Raw []byte
+}
+
+// stanza types
+const (
+	StanzaX25519     = 0x01 // ephemeral public key and the wrapped file key
+	StanzaPassphrase = 0x02 // salt, kdf parameters and the wrapped file key
+	StanzaShares     = 0x03 // split id, threshold, total, salt and the wrapped file key
+	StanzaSSHEd25519 = 0x04 // ssh key tag, ephemeral public key and the wrapped file key
+	StanzaSSHRSA     = 0x05 // ssh key tag and the RSA-OAEP wrapped file key
+)
+
+// Stanza is the file key wrapped for one recipient.
+type Stanza struct {
+	Type byte
+	Body []byte
+}
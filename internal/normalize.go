@@ -0,0 +1,55 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import "golang.org/x/text/unicode/norm"
+
+// PassForm is the unicode normalisation applied to a passphrase before key
+// derivation. macOS tends to produce decomposed (NFD) text where Linux and
+// Windows produce composed (NFC) text, so "é" may arrive as different bytes.
+type PassForm uint8
+
+const (
+	PassRaw PassForm = iota // bytes as typed, files from before normalisation
+	PassNFC                 // written by current versions
+	PassNFD                 // only tried as a fallback for raw files
+)
+
+func (f PassForm) String() string {
+	switch f {
+	case PassRaw:
+		return "raw"
+	case PassNFC:
+		return "NFC"
+	case PassNFD:
+		return "NFD"
+	}
+	return "unknown"
+}
+
+// NormalizePass returns a copy of pass in form f. The copy is never pass
+// itself, so both can be wiped independently.
+func NormalizePass(pass []byte, f PassForm) []byte {
+	switch f {
+	case PassNFC:
+		return norm.NFC.Append(nil, pass...)
+	case PassNFD:
+		return norm.NFD.Append(nil, pass...)
+	}
+	return append([]byte(nil), pass...)
+}
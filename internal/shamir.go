@@ -0,0 +1,119 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Shamir secret sharing over GF(2^8) with the AES polynomial, every byte of
+// the secret is the constant term of its own random polynomial. Share x is
+// the polynomials evaluated at x, so x is never 0.
+
+// gfMul multiplies in GF(2^8) without tables or branches on the values.
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		hi := -(a >> 7)
+		a = a<<1 ^ 0x1b&hi
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns a^254, the inverse of a for every a but 0.
+func gfInv(a byte) byte {
+	r := a
+	for i := 0; i < 6; i++ {
+		r = gfMul(gfMul(r, r), a)
+	}
+	return gfMul(r, r)
+}
+
+// SplitSecret splits secret into total shares, any threshold of which
+// recover it. Share i is evaluated at x = i+1.
+func SplitSecret(secret []byte, threshold, total int) ([][]byte, error) {
+	if threshold < 1 || threshold > total || total > 255 {
+		return nil, fmt.Errorf("invalid split %d of %d", threshold, total)
+	}
+
+	coeffs := make([]byte, (threshold-1)*len(secret))
+	if _, err := rand.Read(coeffs); err != nil {
+		return nil, err
+	}
+	defer Wipe(coeffs)
+
+	shares := make([][]byte, total)
+	for i := range shares {
+		x := byte(i + 1)
+		share := make([]byte, len(secret))
+		for j := range secret {
+			// horner's rule from the highest coefficient down
+			var y byte
+			for k := threshold - 2; k >= 0; k-- {
+				y = gfMul(y, x) ^ coeffs[k*len(secret)+j]
+			}
+			share[j] = gfMul(y, x) ^ secret[j]
+		}
+		shares[i] = share
+	}
+
+	return shares, nil
+}
+
+// InterpolateShares evaluates at x the polynomials through the points xs,
+// ys. x = 0 recovers the secret, any other x what that share should be.
+// xs must be distinct and non-zero, and every ys the same length.
+func InterpolateShares(xs []byte, ys [][]byte, x byte) ([]byte, error) {
+	if len(xs) == 0 || len(xs) != len(ys) {
+		return nil, fmt.Errorf("need one share per x, got %d x and %d shares", len(xs), len(ys))
+	}
+
+	// a zero or repeated x has no inverse in the basis below, the result
+	// would be garbage rather than an error
+	var seen [256]bool
+	for i, xi := range xs {
+		if xi == 0 {
+			return nil, errors.New("share x must not be 0")
+		}
+		if seen[xi] {
+			return nil, fmt.Errorf("share x %d given twice", xi)
+		}
+		seen[xi] = true
+		if len(ys[i]) != len(ys[0]) {
+			return nil, errors.New("shares differ in length")
+		}
+	}
+
+	out := make([]byte, len(ys[0]))
+	for i, xi := range xs {
+		// lagrange basis polynomial i at x, subtraction is xor
+		basis := byte(1)
+		for j, xj := range xs {
+			if i != j {
+				basis = gfMul(basis, gfMul(x^xj, gfInv(xi^xj)))
+			}
+		}
+		for k := range out {
+			out[k] ^= gfMul(basis, ys[i][k])
+		}
+	}
+	return out, nil
+}
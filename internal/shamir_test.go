@@ -0,0 +1,137 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/bits"
+	"testing"
+)
+
+func TestGFInverse(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		if got := gfMul(byte(a), gfInv(byte(a))); got != 1 {
+			t.Fatalf("%d * inverse = %d", a, got)
+		}
+	}
+}
+
+// points returns the x and y of the shares picked by mask, share i being
+// evaluated at x = i+1.
+func points(shares [][]byte, mask int) ([]byte, [][]byte) {
+	var xs []byte
+	var ys [][]byte
+	for i := range shares {
+		if mask&(1<<i) != 0 {
+			xs = append(xs, byte(i+1))
+			ys = append(ys, shares[i])
+		}
+	}
+	return xs, ys
+}
+
+func TestShamirThresholds(t *testing.T) {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+
+	for total := 1; total <= 6; total++ {
+		for threshold := 1; threshold <= total; threshold++ {
+			shares, err := SplitSecret(secret, threshold, total)
+			if err != nil {
+				t.Fatalf("%d of %d: %v", threshold, total, err)
+			}
+
+			// every subset of shares, those of threshold size or more
+			// recover the secret and one short of it must not
+			for mask := 1; mask < 1<<total; mask++ {
+				n := bits.OnesCount(uint(mask))
+				if n != threshold && n != threshold-1 {
+					continue
+				}
+				xs, ys := points(shares, mask)
+				got, err := InterpolateShares(xs, ys, 0)
+				if err != nil {
+					t.Fatalf("%d of %d, shares %b: %v", threshold, total, mask, err)
+				}
+
+				if n == threshold && !bytes.Equal(got, secret) {
+					t.Fatalf("%d of %d, shares %b: wrong secret", threshold, total, mask)
+				}
+				if n < threshold && bytes.Equal(got, secret) {
+					t.Fatalf("%d of %d, shares %b: recovered with too few shares", threshold, total, mask)
+				}
+			}
+
+			// the first threshold shares predict all the others
+			xs, ys := points(shares, 1<<threshold-1)
+			for i := threshold; i < total; i++ {
+				got, err := InterpolateShares(xs, ys, byte(i+1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, shares[i]) {
+					t.Fatalf("%d of %d: share %d does not lie on the polynomials", threshold, total, i+1)
+				}
+			}
+		}
+	}
+}
+
+func TestSplitSecretRejects(t *testing.T) {
+	tests := []struct {
+		name             string
+		threshold, total int
+	}{
+		{name: "zero threshold", threshold: 0, total: 3},
+		{name: "threshold above total", threshold: 4, total: 3},
+		{name: "too many shares", threshold: 2, total: 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SplitSecret([]byte("secret"), tt.threshold, tt.total); err == nil {
+				t.Fatalf("split %d of %d", tt.threshold, tt.total)
+			}
+		})
+	}
+}
+
+func TestInterpolateSharesRejects(t *testing.T) {
+	y := []byte{1, 2, 3}
+
+	tests := []struct {
+		name string
+		xs   []byte
+		ys   [][]byte
+	}{
+		{name: "no shares"},
+		{name: "duplicate x", xs: []byte{1, 1}, ys: [][]byte{y, y}},
+		{name: "duplicate x among others", xs: []byte{1, 2, 3, 2}, ys: [][]byte{y, y, y, y}},
+		{name: "zero x", xs: []byte{0, 1}, ys: [][]byte{y, y}},
+		{name: "more x than shares", xs: []byte{1, 2}, ys: [][]byte{y}},
+		{name: "different lengths", xs: []byte{1, 2}, ys: [][]byte{y, y[:2]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := InterpolateShares(tt.xs, tt.ys, 0); err == nil {
+				t.Fatalf("got %x, want an error", got)
+			}
+		})
+	}
+}
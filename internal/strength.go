@@ -0,0 +1,328 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The estimator follows zxcvbn: the passphrase is covered by the sequence of
+// known patterns (dictionary words, keyboard walks, repeats, sequences, dates)
+// and random characters that needs the fewest guesses. All guess counts are
+// kept as log10 so long passphrases do not overflow.
+
+const (
+	// passphrases are only matched up to this many characters, anything
+	// beyond counts as random
+	maxStrengthRunes = 100
+
+	// log10 of the extra guesses for every pattern added to a sequence
+	sequencePenalty = 4
+)
+
+// scoreThresholds are the log10 guesses needed for scores 1 to 4.
+var scoreThresholds = [...]float64{3, 6, 8, 10}
+
+// Strength is an estimate of how hard a passphrase is to guess.
+type Strength struct {
+	// Score ranges from 0 (very weak) to 4 (strong).
+	Score int `json:"score"`
+	// Guesses is log10 of the estimated number of guesses.
+	Guesses     float64  `json:"guesses_log10"`
+	Warning     string   `json:"warning,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// match is a pattern found at runes i to j inclusive.
+type match struct {
+	i, j    int
+	pattern string
+	token   []rune
+	guesses float64 // log10
+
+	// dictionary
+	dictionary string
+	rank       int
+	reversed   bool
+	l33t       bool
+
+	// spatial
+	turns int
+
+	// repeat
+	repeats int
+}
+
+// EstimateStrength scores pass. userInputs are words an attacker is likely to
+// try first, such as the file name.
+func EstimateStrength(pass []byte, userInputs []string) Strength {
+	runes := make([]rune, 0, utf8.RuneCount(pass))
+	for b := pass; len(b) > 0; {
+		r, size := utf8.DecodeRune(b)
+		runes = append(runes, r)
+		b = b[size:]
+	}
+	defer clear(runes)
+
+	// the tail of very long passphrases counts as random characters
+	var extra float64
+	if len(runes) > maxStrengthRunes {
+		extra = float64(len(runes) - maxStrengthRunes)
+		runes = runes[:maxStrengthRunes]
+	}
+
+	guesses, sequence := mostGuessable(runes, findMatches(runes, userDictionary(userInputs)))
+	guesses += extra
+
+	s := Strength{Guesses: guesses}
+	for _, t := range scoreThresholds {
+		if guesses >= t {
+			s.Score++
+		}
+	}
+	s.Warning, s.Suggestions = feedback(s.Score, sequence)
+
+	return s
+}
+
+// mostGuessable returns the log10 guesses of the cheapest sequence of
+// matches covering runes, filling gaps with bruteforce matches.
+func mostGuessable(runes []rune, matches []match) (float64, []match) {
+	n := len(runes)
+	if n == 0 {
+		return 0, nil
+	}
+
+	type step struct {
+		pi  float64 // log10 of the product of the match guesses
+		g   float64 // log10 of the guesses of the whole sequence
+		seq []match
+	}
+
+	// best[k][l] is the cheapest sequence of l matches covering runes[:k+1]
+	best := make([]map[int]step, n)
+	for k := range best {
+		best[k] = map[int]step{}
+	}
+
+	update := func(m match, l int, prev step) {
+		pi := m.guesses
+		if l > 1 {
+			pi += prev.pi
+		}
+
+		// l! orderings of the patterns plus a penalty per extra pattern
+		g := logAdd(logFactorial(l)+pi, float64(l-1)*sequencePenalty)
+
+		// a shorter sequence that is at least as good makes this one useless
+		for other, s := range best[m.j] {
+			if other <= l && s.g <= g {
+				return
+			}
+		}
+
+		seq := make([]match, len(prev.seq), len(prev.seq)+1)
+		copy(seq, prev.seq)
+		best[m.j][l] = step{pi: pi, g: g, seq: append(seq, m)}
+	}
+
+	byEnd := make([][]match, n)
+	for _, m := range matches {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			m.guesses = math.Max(m.guesses, minGuesses(m, n))
+			if m.i == 0 {
+				update(m, 1, step{})
+				continue
+			}
+			for l, prev := range best[m.i-1] {
+				update(m, l+1, prev)
+			}
+		}
+
+		// random characters never follow other random characters, they
+		// would be one longer bruteforce match instead
+		for i := 0; i <= k; i++ {
+			bf := bruteforceMatch(runes, i, k)
+			if i == 0 {
+				update(bf, 1, step{})
+				continue
+			}
+			for l, prev := range best[i-1] {
+				if prev.seq[len(prev.seq)-1].pattern != "bruteforce" {
+					update(bf, l+1, prev)
+				}
+			}
+		}
+	}
+
+	var result step
+	first := true
+	for _, s := range best[n-1] {
+		if first || s.g < result.g {
+			result = s
+			first = false
+		}
+	}
+
+	return result.g, result.seq
+}
+
+// minGuesses keeps a pattern inside a longer passphrase from counting as
+// cheaper than a few random characters.
+func minGuesses(m match, n int) float64 {
+	switch {
+	case len(m.token) == n:
+		return 0
+	case len(m.token) == 1:
+		return 1
+	default:
+		return math.Log10(50)
+	}
+}
+
+// bruteforceMatch treats runes i to j as random, with 10 guesses per rune.
+func bruteforceMatch(runes []rune, i, j int) match {
+	guesses := float64(j - i + 1)
+	if i == j {
+		guesses = math.Log10(11)
+	}
+	return match{i: i, j: j, pattern: "bruteforce", token: runes[i : j+1], guesses: guesses}
+}
+
+// feedback explains a low score by the longest pattern in sequence.
+func feedback(score int, sequence []match) (string, []string) {
+	if len(sequence) == 0 {
+		return "", []string{"use a few words, avoid common phrases", "no need for symbols, digits or uppercase letters"}
+	}
+	if score > 2 {
+		return "", nil
+	}
+
+	longest := sequence[0]
+	for _, m := range sequence[1:] {
+		if len(m.token) > len(longest.token) {
+			longest = m
+		}
+	}
+
+	warning, suggestions := matchFeedback(longest, len(sequence) == 1)
+	suggestions = append([]string{"add another word or two, uncommon words are better"}, suggestions...)
+
+	return warning, suggestions
+}
+
+func matchFeedback(m match, sole bool) (string, []string) {
+	switch m.pattern {
+	case "dictionary":
+		return dictionaryFeedback(m, sole)
+
+	case "spatial":
+		if m.turns == 1 {
+			return "straight rows of keys are easy to guess", []string{"use a longer keyboard pattern with more turns"}
+		}
+		return "short keyboard patterns are easy to guess", []string{"use a longer keyboard pattern with more turns"}
+
+	case "repeat":
+		if len(m.token)/m.repeats == 1 {
+			return `repeats like "aaa" are easy to guess`, []string{"avoid repeated words and characters"}
+		}
+		return `repeats like "abcabcabc" are only slightly harder to guess than "abc"`, []string{"avoid repeated words and characters"}
+
+	case "sequence":
+		return `sequences like "abc" or "6543" are easy to guess`, []string{"avoid sequences"}
+
+	case "year":
+		return "recent years are easy to guess", []string{"avoid recent years", "avoid years that are associated with you"}
+
+	case "date":
+		return "dates are often easy to guess", []string{"avoid dates and years that are associated with you"}
+	}
+
+	return "", []string{"use a longer passphrase"}
+}
+
+func dictionaryFeedback(m match, sole bool) (string, []string) {
+	var warning string
+
+	switch m.dictionary {
+	case "passwords":
+		switch {
+		case sole && !m.l33t && !m.reversed && m.rank <= 10:
+			warning = "this is a top-10 common password"
+		case sole && !m.l33t && !m.reversed && m.rank <= 100:
+			warning = "this is a top-100 common password"
+		case sole && !m.l33t && !m.reversed:
+			warning = "this is a very common password"
+		default:
+			warning = "this is similar to a commonly used password"
+		}
+	case "english":
+		if sole {
+			warning = "a word by itself is easy to guess"
+		}
+	case "user_inputs":
+		warning = "passphrases based on the file name are easy to guess"
+	}
+
+	var suggestions []string
+	upper := 0
+	for _, r := range m.token {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+
+	switch {
+	case upper == len(m.token) && len(m.token) > 1:
+		suggestions = append(suggestions, "all-uppercase is almost as easy to guess as all-lowercase")
+	case upper > 0 && unicode.IsUpper(m.token[0]):
+		suggestions = append(suggestions, "capitalization doesn't help very much")
+	}
+	if m.reversed && len(m.token) >= 4 {
+		suggestions = append(suggestions, "reversed words aren't much harder to guess")
+	}
+	if m.l33t {
+		suggestions = append(suggestions, "predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+
+	return warning, suggestions
+}
+
+// logAdd returns log10(10^a + 10^b).
+func logAdd(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log10(1+math.Pow(10, b-a))
+}
+
+func logFactorial(n int) float64 {
+	lg, _ := math.Lgamma(float64(n) + 1)
+	return lg / math.Ln10
+}
+
+// logBinomial returns log10 of n choose k.
+func logBinomial(n, k int) float64 {
+	return logFactorial(n) - logFactorial(k) - logFactorial(n-k)
+}
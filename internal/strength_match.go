@@ -0,0 +1,678 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package internal
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//go:embed wordlists/passwords.txt
+var passwordList string
+
+//go:embed wordlists/english.txt
+var englishList string
+
+// dictionary maps lowercase words to their rank, 1 being the most common.
+type dictionary struct {
+	name  string
+	ranks map[string]int
+}
+
+var dictionaries = []dictionary{
+	{"passwords", rankWords(strings.Fields(passwordList))},
+	{"english", rankWords(strings.Fields(englishList))},
+}
+
+func rankWords(words []string) map[string]int {
+	ranks := make(map[string]int, len(words))
+	for i, w := range words {
+		w = strings.ToLower(w)
+		if _, ok := ranks[w]; !ok {
+			ranks[w] = i + 1
+		}
+	}
+	return ranks
+}
+
+func userDictionary(inputs []string) dictionary {
+	return dictionary{"user_inputs", rankWords(inputs)}
+}
+
+// findMatches runs every matcher over runes.
+func findMatches(runes []rune, user dictionary) []match {
+	dicts := append([]dictionary{user}, dictionaries...)
+
+	var matches []match
+	matches = append(matches, dictionaryMatches(runes, dicts)...)
+	matches = append(matches, reversedMatches(runes, dicts)...)
+	matches = append(matches, l33tMatches(runes, dicts)...)
+	matches = append(matches, spatialMatches(runes)...)
+	matches = append(matches, repeatMatches(runes, user)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, dateMatches(runes)...)
+
+	return matches
+}
+
+// dictionaryMatches finds every substring of runes that is a known word.
+func dictionaryMatches(runes []rune, dicts []dictionary) []match {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var matches []match
+	for i := range lower {
+		for j := i; j < len(lower); j++ {
+			word := string(lower[i : j+1])
+			for _, d := range dicts {
+				rank, ok := d.ranks[word]
+				if !ok {
+					continue
+				}
+				token := runes[i : j+1]
+				matches = append(matches, match{
+					i: i, j: j,
+					pattern:    "dictionary",
+					token:      token,
+					dictionary: d.name,
+					rank:       rank,
+					guesses:    math.Log10(float64(rank)) + uppercaseVariations(token),
+				})
+			}
+		}
+	}
+
+	return matches
+}
+
+// reversedMatches finds words written backwards.
+func reversedMatches(runes []rune, dicts []dictionary) []match {
+	n := len(runes)
+	reversed := make([]rune, n)
+	for i, r := range runes {
+		reversed[n-1-i] = r
+	}
+
+	var matches []match
+	for _, m := range dictionaryMatches(reversed, dicts) {
+		if len(m.token) < 2 {
+			continue
+		}
+		m.i, m.j = n-1-m.j, n-1-m.i
+		m.token = runes[m.i : m.j+1]
+		m.reversed = true
+		m.guesses += math.Log10(2)
+		matches = append(matches, m)
+	}
+
+	return matches
+}
+
+// l33tTable lists the letters commonly replaced by each character.
+var l33tTable = map[rune][]rune{
+	'4': {'a'}, '@': {'a'}, '8': {'b'}, '(': {'c'}, '{': {'c'}, '[': {'c'}, '<': {'c'},
+	'3': {'e'}, '6': {'g'}, '9': {'g'}, '1': {'i', 'l'}, '!': {'i'}, '|': {'i', 'l'},
+	'0': {'o'}, '$': {'s'}, '5': {'s'}, '+': {'t'}, '7': {'t', 'l'}, '%': {'x'}, '2': {'z'},
+}
+
+// maxL33tVariants bounds how many substitution combinations are tried.
+const maxL33tVariants = 16
+
+// l33tMatches finds words with letters replaced by look-alike characters.
+func l33tMatches(runes []rune, dicts []dictionary) []match {
+	variants := [][]rune{append([]rune(nil), runes...)}
+	subbed := false
+
+	for pos, r := range runes {
+		letters, ok := l33tTable[r]
+		if !ok {
+			continue
+		}
+		subbed = true
+
+		for _, v := range variants {
+			v[pos] = letters[0]
+		}
+		for _, letter := range letters[1:] {
+			for _, v := range variants {
+				if len(variants) >= maxL33tVariants {
+					break
+				}
+				alt := append([]rune(nil), v...)
+				alt[pos] = letter
+				variants = append(variants, alt)
+			}
+		}
+	}
+	if !subbed {
+		return nil
+	}
+
+	var matches []match
+	seen := map[[3]int]bool{}
+	for _, v := range variants {
+		for _, m := range dictionaryMatches(v, dicts) {
+			token := runes[m.i : m.j+1]
+			variations := l33tVariations(token, v[m.i:m.j+1])
+			if len(token) < 2 || variations == 0 {
+				continue
+			}
+
+			key := [3]int{m.i, m.j, m.rank}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			m.token = token
+			m.l33t = true
+			m.guesses = math.Log10(float64(m.rank)) + uppercaseVariations(token) + variations
+			matches = append(matches, m)
+		}
+	}
+
+	return matches
+}
+
+// l33tVariations returns log10 of the ways the substitutions in token could
+// have been chosen, or 0 when nothing was substituted.
+func l33tVariations(token, plain []rune) float64 {
+	type count struct{ subbed, unsubbed int }
+	counts := map[rune]*count{}
+
+	for i, r := range token {
+		letter := plain[i]
+		c, ok := counts[letter]
+		if !ok {
+			c = &count{}
+			counts[letter] = c
+		}
+		if r != letter && unicode.ToLower(r) != letter {
+			c.subbed++
+		} else {
+			c.unsubbed++
+		}
+	}
+
+	var variations float64
+	for _, c := range counts {
+		switch {
+		case c.subbed == 0:
+		case c.unsubbed == 0:
+			variations += math.Log10(2)
+		default:
+			variations += math.Log10(sumBinomials(c.subbed+c.unsubbed, min(c.subbed, c.unsubbed)))
+		}
+	}
+
+	return variations
+}
+
+// uppercaseVariations returns log10 of the ways token could have been
+// capitalised, a capital first or last letter or all capitals are common.
+func uppercaseVariations(token []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 0
+	case lower == 0, upper == 1 && (unicode.IsUpper(token[0]) || unicode.IsUpper(token[len(token)-1])):
+		return math.Log10(2)
+	}
+
+	return math.Log10(sumBinomials(upper+lower, min(upper, lower)))
+}
+
+// sumBinomials returns the sum of n choose i for i from 1 to k.
+func sumBinomials(n, k int) float64 {
+	var sum float64
+	for i := 1; i <= k; i++ {
+		sum += math.Pow(10, logBinomial(n, i))
+	}
+	return sum
+}
+
+// keyboard is a qwerty layout without the backtick key, so that each row is
+// offset half a key to the right of the row above.
+var keyboard = [][2]string{
+	{"1234567890-=", "!@#$%^&*()_+"},
+	{"qwertyuiop[]\\", "QWERTYUIOP{}|"},
+	{"asdfghjkl;'", "ASDFGHJKL:\""},
+	{"zxcvbnm,./", "ZXCVBNM<>?"},
+}
+
+type keyPos struct {
+	row, col int
+	shifted  bool
+}
+
+var keyPositions, keyboardKeys, keyboardDegree = buildKeyboard()
+
+func buildKeyboard() (map[rune]keyPos, int, float64) {
+	positions := map[rune]keyPos{}
+	for row, keys := range keyboard {
+		for col, r := range []rune(keys[0]) {
+			positions[r] = keyPos{row: row, col: col}
+		}
+		for col, r := range []rune(keys[1]) {
+			positions[r] = keyPos{row: row, col: col, shifted: true}
+		}
+	}
+
+	keys, degree := 0, 0
+	for row, k := range keyboard {
+		for col := range []rune(k[0]) {
+			keys++
+			for d := range keyDirections {
+				if _, ok := neighbour(row, col, d); ok {
+					degree++
+				}
+			}
+		}
+	}
+
+	return positions, keys, float64(degree) / float64(keys)
+}
+
+// keyDirections are the row and column offsets of the six neighbours of a key.
+var keyDirections = [][2]int{{0, -1}, {0, 1}, {-1, 0}, {-1, 1}, {1, -1}, {1, 0}}
+
+func neighbour(row, col, d int) (rune, bool) {
+	row += keyDirections[d][0]
+	col += keyDirections[d][1]
+	if row < 0 || row >= len(keyboard) || col < 0 {
+		return 0, false
+	}
+	keys := []rune(keyboard[row][0])
+	if col >= len(keys) {
+		return 0, false
+	}
+	return keys[col], true
+}
+
+// direction returns which neighbour of a the key b is, or -1.
+func direction(a, b rune) int {
+	pa, ok := keyPositions[a]
+	if !ok {
+		return -1
+	}
+	pb, ok := keyPositions[b]
+	if !ok {
+		return -1
+	}
+	for d := range keyDirections {
+		if pa.row+keyDirections[d][0] == pb.row && pa.col+keyDirections[d][1] == pb.col {
+			return d
+		}
+	}
+	return -1
+}
+
+// spatialMatches finds walks of three or more adjacent keys.
+func spatialMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(runes)-1; {
+		j, turns, last := i, 0, -1
+		for j+1 < len(runes) {
+			d := direction(runes[j], runes[j+1])
+			if d < 0 {
+				break
+			}
+			if d != last {
+				turns++
+				last = d
+			}
+			j++
+		}
+
+		if j-i >= 2 {
+			token := runes[i : j+1]
+			matches = append(matches, match{
+				i: i, j: j,
+				pattern: "spatial",
+				token:   token,
+				turns:   turns,
+				guesses: spatialGuesses(token, turns),
+			})
+		}
+
+		if j > i {
+			i = j
+		} else {
+			i++
+		}
+	}
+
+	return matches
+}
+
+func spatialGuesses(token []rune, turns int) float64 {
+	var guesses float64
+	for l := 2; l <= len(token); l++ {
+		for t := 1; t <= min(turns, l-1); t++ {
+			guesses += math.Pow(10, logBinomial(l-1, t-1)) * float64(keyboardKeys) * math.Pow(keyboardDegree, float64(t))
+		}
+	}
+
+	shifted := 0
+	for _, r := range token {
+		if keyPositions[r].shifted {
+			shifted++
+		}
+	}
+
+	variations := 0.0
+	switch {
+	case shifted == 0:
+	case shifted == len(token):
+		variations = math.Log10(2)
+	default:
+		variations = math.Log10(sumBinomials(len(token), min(shifted, len(token)-shifted)))
+	}
+
+	return math.Log10(guesses) + variations
+}
+
+// repeatMatches finds a run of the same characters or words repeated back to
+// back, taking the longest run with the shortest base at each position.
+func repeatMatches(runes []rune, user dictionary) []match {
+	var matches []match
+
+	for i := 0; i < len(runes); {
+		bestBase, bestCount := 0, 0
+		for base := 1; i+2*base <= len(runes); base++ {
+			count := 1
+			for next := i + base; next+base <= len(runes) && equalRunes(runes[i:i+base], runes[next:next+base]); next += base {
+				count++
+			}
+			if count > 1 && base*count > bestBase*bestCount {
+				bestBase, bestCount = base, count
+			}
+		}
+
+		if bestCount == 0 {
+			i++
+			continue
+		}
+
+		j := i + bestBase*bestCount - 1
+		base := runes[i : i+bestBase]
+		baseGuesses, _ := mostGuessable(base, findMatches(base, user))
+
+		matches = append(matches, match{
+			i: i, j: j,
+			pattern: "repeat",
+			token:   runes[i : j+1],
+			repeats: bestCount,
+			guesses: baseGuesses + math.Log10(float64(bestCount)),
+		})
+		i = j + 1
+	}
+
+	return matches
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// maxSequenceDelta is the largest step between characters of a sequence.
+const maxSequenceDelta = 5
+
+// sequenceMatches finds runs like "abc", "2468" or "zyx".
+func sequenceMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(runes)-2; {
+		delta := runes[i+1] - runes[i]
+		j := i + 1
+		if delta != 0 && abs(delta) <= maxSequenceDelta && sameClass(runes[i], runes[j]) {
+			for j+1 < len(runes) && runes[j+1]-runes[j] == delta && sameClass(runes[j], runes[j+1]) {
+				j++
+			}
+		}
+
+		if j-i >= 2 {
+			token := runes[i : j+1]
+			matches = append(matches, match{
+				i: i, j: j,
+				pattern: "sequence",
+				token:   token,
+				guesses: sequenceGuesses(token, delta),
+			})
+			i = j
+			continue
+		}
+		i++
+	}
+
+	return matches
+}
+
+func sequenceGuesses(token []rune, delta rune) float64 {
+	var base float64
+	switch first := token[0]; {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if delta < 0 {
+		base *= 2
+	}
+	return math.Log10(base * float64(len(token)))
+}
+
+func sameClass(a, b rune) bool {
+	switch {
+	case unicode.IsDigit(a):
+		return unicode.IsDigit(b)
+	case unicode.IsLower(a):
+		return unicode.IsLower(b)
+	case unicode.IsUpper(a):
+		return unicode.IsUpper(b)
+	}
+	return false
+}
+
+func abs(r rune) rune {
+	if r < 0 {
+		return -r
+	}
+	return r
+}
+
+const (
+	minYear = 1000
+	maxYear = 2050
+
+	// years closer than this to now are all equally likely
+	minYearSpace = 20
+)
+
+// dateSeparators may split the day, month and year of a date.
+const dateSeparators = "/\\_.- "
+
+// dateMatches finds years and dates with or without separators.
+func dateMatches(runes []rune) []match {
+	var matches []match
+
+	// years on their own
+	for i := 0; i+4 <= len(runes); i++ {
+		if year, ok := parseDigits(runes[i : i+4]); ok && year >= 1900 && year <= maxYear {
+			matches = append(matches, match{
+				i: i, j: i + 3,
+				pattern: "year",
+				token:   runes[i : i+4],
+				guesses: math.Log10(yearSpace(year)),
+			})
+		}
+	}
+
+	// 4 to 8 digits without separators
+	for i := range runes {
+		for j := i + 3; j < len(runes) && j < i+8; j++ {
+			token := runes[i : j+1]
+			if _, ok := parseDigits(token); !ok {
+				break
+			}
+			if guesses, ok := bestDate(token); ok {
+				matches = append(matches, match{i: i, j: j, pattern: "date", token: token, guesses: guesses})
+			}
+		}
+	}
+
+	// d-m-y with the same separator twice
+	for i := range runes {
+		for j := i + 5; j < len(runes) && j < i+10; j++ {
+			token := runes[i : j+1]
+			if guesses, ok := separatedDate(token); ok {
+				matches = append(matches, match{i: i, j: j, pattern: "date", token: token, guesses: guesses})
+			}
+		}
+	}
+
+	return matches
+}
+
+// bestDate tries every split of digits into day, month and year and returns
+// log10 guesses of the likeliest.
+func bestDate(digits []rune) (float64, bool) {
+	best, found := 0.0, false
+
+	for a := 1; a < len(digits)-1; a++ {
+		for b := a + 1; b < len(digits); b++ {
+			if guesses, ok := dateGuesses(digits[:a], digits[a:b], digits[b:]); ok && (!found || guesses < best) {
+				best, found = guesses, true
+			}
+		}
+	}
+
+	return best, found
+}
+
+func separatedDate(token []rune) (float64, bool) {
+	var parts [][]rune
+	var sep rune
+	start := 0
+
+	for k, r := range token {
+		if !strings.ContainsRune(dateSeparators, r) {
+			continue
+		}
+		if sep != 0 && r != sep {
+			return 0, false
+		}
+		sep = r
+		parts = append(parts, token[start:k])
+		start = k + 1
+	}
+	parts = append(parts, token[start:])
+
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	guesses, ok := dateGuesses(parts[0], parts[1], parts[2])
+	return guesses + math.Log10(4), ok
+}
+
+// dateGuesses reads the parts as year first or last, with day and month in
+// either order.
+func dateGuesses(a, b, c []rune) (float64, bool) {
+	type candidate struct{ year, x, y []rune }
+
+	for _, cand := range []candidate{{a, b, c}, {c, a, b}} {
+		if len(cand.year) != 2 && len(cand.year) != 4 || len(cand.x) > 2 || len(cand.y) > 2 {
+			continue
+		}
+
+		year, ok := parseDigits(cand.year)
+		x, okx := parseDigits(cand.x)
+		y, oky := parseDigits(cand.y)
+		if !ok || !okx || !oky {
+			continue
+		}
+
+		if len(cand.year) == 2 {
+			if year < 50 {
+				year += 2000
+			} else {
+				year += 1900
+			}
+		}
+		if year < minYear || year > maxYear {
+			continue
+		}
+
+		if validDayMonth(x, y) || validDayMonth(y, x) {
+			return math.Log10(yearSpace(year) * 365), true
+		}
+	}
+
+	return 0, false
+}
+
+func validDayMonth(day, month int) bool {
+	return day >= 1 && day <= 31 && month >= 1 && month <= 12
+}
+
+func yearSpace(year int) float64 {
+	space := year - time.Now().Year()
+	if space < 0 {
+		space = -space
+	}
+	return float64(max(space, minYearSpace))
+}
+
+// parseDigits parses runes as a decimal number.
+func parseDigits(runes []rune) (int, bool) {
+	if len(runes) == 0 {
+		return 0, false
+	}
+
+	n := 0
+	for _, r := range runes {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+		n = n*10 + int(r-'0')
+	}
+	return n, true
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2026 irrisdev lithium8260@proton.me
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+// minGood is the score of "good", the default --min-strength.
+const minGood = 3
+
+func TestEstimateStrength(t *testing.T) {
+	tests := []struct {
+		name    string
+		pass    string
+		inputs  []string
+		good    bool
+		warning string
+	}{
+		// keyboard walks
+		{name: "row walk", pass: "asdfghjkl;", warning: "rows of keys"},
+		{name: "bottom row walk", pass: "zxcvbnm,./", warning: "rows of keys"},
+		{name: "column walk", pass: "1qaz2wsx3edc"},
+		{name: "reversed walk", pass: "poiuytrewq"},
+
+		// repeats and sequences
+		{name: "one character", pass: "xxxxxxxxxxxxxxxxxxxx", warning: "repeats like \"aaa\""},
+		{name: "repeated pair", pass: "abababababab", warning: "repeats like \"abcabcabc\""},
+		{name: "repeated word", pass: "monkeymonkeymonkey", warning: "repeats like \"abcabcabc\""},
+		{name: "repeated digits", pass: "123123123123", warning: "repeats like \"abcabcabc\""},
+		{name: "alphabet", pass: "abcdefghijkl", warning: "sequences"},
+		{name: "descending", pass: "zyxwvutsrq", warning: "sequences"},
+		{name: "date", pass: "01/02/1987", warning: "dates"},
+
+		// dictionary words
+		{name: "top password", pass: "password", warning: "top-10"},
+		{name: "common word", pass: "orange", warning: "very common"},
+		{name: "l33t", pass: "P@ssw0rd", warning: "similar to a commonly used"},
+		{name: "reversed word", pass: "drowssap", warning: "similar to a commonly used"},
+		{name: "word and digits", pass: "football1987"},
+		{name: "file name", pass: "secrets2024", inputs: []string{"secrets"}, warning: "file name"},
+
+		// enough for the default
+		{name: "two words", pass: "orange purple", good: true},
+		{name: "four words", pass: "correct horse battery staple", good: true},
+		{name: "random", pass: "kX9#mQ2$vL7!pR4z", good: true},
+		{name: "random short", pass: "b8Vq!2mZ", good: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := EstimateStrength([]byte(tt.pass), tt.inputs)
+
+			if good := s.Score >= minGood; good != tt.good {
+				t.Fatalf("%q scored %d (%.1f), good %v, want %v", tt.pass, s.Score, s.Guesses, good, tt.good)
+			}
+			if !strings.Contains(s.Warning, tt.warning) {
+				t.Fatalf("%q warned %q, want %q", tt.pass, s.Warning, tt.warning)
+			}
+			if !tt.good && len(s.Suggestions) == 0 {
+				t.Fatalf("%q got no suggestions", tt.pass)
+			}
+
+			// the score is the number of thresholds the guesses reach
+			score := 0
+			for _, th := range scoreThresholds {
+				if s.Guesses >= th {
+					score++
+				}
+			}
+			if s.Score != score {
+				t.Fatalf("%q scored %d for %.1f guesses, want %d", tt.pass, s.Score, s.Guesses, score)
+			}
+		})
+	}
+}
+
+func TestEstimateStrengthGrowsWithWords(t *testing.T) {
+	var prev float64
+	pass := ""
+	for _, w := range []string{"purple", "elephant", "guitar", "window"} {
+		pass = strings.TrimSpace(pass + " " + w)
+		s := EstimateStrength([]byte(pass), nil)
+		if s.Guesses <= prev {
+			t.Fatalf("%q needs %.1f guesses, no more than %.1f before", pass, s.Guesses, prev)
+		}
+		prev = s.Guesses
+	}
+}
+
+func TestEstimateStrengthLong(t *testing.T) {
+	// everything beyond maxStrengthRunes counts as random, it must not be slow
+	// or overflow
+	s := EstimateStrength([]byte(strings.Repeat("a", 10*maxStrengthRunes)), nil)
+	if s.Guesses <= 0 || s.Score < 0 || s.Score > 4 {
+		t.Fatalf("got %+v", s)
+	}
+}